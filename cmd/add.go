@@ -5,14 +5,22 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/limelamp/osmium/internal/shared"
 	"github.com/spf13/cobra"
 )
 
 type addFlags struct {
-	modFlag    bool
-	pluginFlag bool
+	modFlag     bool
+	pluginFlag  bool
+	source      string
+	url         string
+	file        string
+	github      string
+	asset       string
+	name        string
+	allowClient bool
 }
 
 var addflags addFlags
@@ -20,14 +28,35 @@ var addflags addFlags
 // addCmd represents the add command
 var addCmd = &cobra.Command{
 	Use:   "add [project-id ...]",
-	Short: "Add one or more Modrinth projects.",
-	Long: `Downloads and installs one or more Modrinth projects.
+	Short: "Add one or more projects.",
+	Long: `Downloads and installs one or more projects from Modrinth, or from another source with --source.
 
-Use --mod for mods or --plugin for plugins.
+Use --mod for mods or --plugin for plugins. osmium.json records the source of every project,
+so install and update use it later on. Hangar (PaperMC) has plugins for Paper, Velocity and Waterfall,
+CurseForge has mods and needs an API key (see 'osmium curseforge').
+
+Modrinth mods that are client-side only (shaders, minimaps, Sodium) crash servers and are refused,
+client-only dependencies are skipped. --allow-client installs them anyway, with a warning.
+
+Jars that aren't on any source can be added with --url or --file. osmium.json records their hash
+and where they came from, so install puts them back, but update skips them. With --github owner/repo
+the project follows that repository's releases instead, GitHub release URLs do so on their own.
+--asset picks the release file (a glob like "MyPlugin-*.jar"), --name sets the name in osmium.json.
 Examples:
-  osmium add --mod sodium
-  osmium add --plugin luckperms viaversion`,
-	Args: cobra.MinimumNArgs(1),
+  osmium add --mod lithium
+  osmium add --mod --allow-client sodium
+  osmium add --plugin luckperms viaversion
+  osmium add --plugin --source hangar Maintenance
+  osmium add --mod --source curseforge jei
+  osmium add --plugin --url https://example.com/MyPlugin-1.2.jar
+  osmium add --plugin --file ./MyPlugin.jar --name myplugin
+  osmium add --plugin --github EssentialsX/Essentials --asset "EssentialsX-*.jar"`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if addflags.url != "" || addflags.file != "" || addflags.github != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		var projectType string
 
@@ -41,8 +70,24 @@ Examples:
 			return
 		}
 
+		shared.AllowClientOnly = addflags.allowClient
+
+		if addflags.url != "" || addflags.file != "" || addflags.github != "" {
+			project := shared.DirectProject{
+				URL:   addflags.url,
+				File:  addflags.file,
+				Repo:  addflags.github,
+				Asset: addflags.asset,
+				Name:  addflags.name,
+			}
+			if err := shared.AddDirectProject(project, projectType); err != nil {
+				fmt.Println(err)
+			}
+			return
+		}
+
 		for _, projectID := range args {
-			if err := shared.AddProjectByID(projectID, projectType); err != nil {
+			if err := shared.AddProject(addflags.source, projectID, projectType); err != nil {
 				fmt.Println(err)
 			}
 		}
@@ -54,8 +99,18 @@ func init() {
 
 	addCmd.Flags().BoolVarP(&addflags.modFlag, "mod", "m", false, "Download as mod")
 	addCmd.Flags().BoolVarP(&addflags.pluginFlag, "plugin", "p", false, "Download as plugin")
+	addCmd.Flags().StringVar(&addflags.source, "source", shared.SourceModrinth, "Where to download from: "+strings.Join(shared.SourceNames(), ", "))
+
+	addCmd.Flags().BoolVar(&addflags.allowClient, "allow-client", false, "Install client-side only mods with a warning instead of refusing them")
+
+	addCmd.Flags().StringVar(&addflags.url, "url", "", "Download a jar from a URL")
+	addCmd.Flags().StringVar(&addflags.file, "file", "", "Add a local jar")
+	addCmd.Flags().StringVar(&addflags.github, "github", "", "Follow the releases of a GitHub repository (owner/repo)")
+	addCmd.Flags().StringVar(&addflags.asset, "asset", "", "Glob of the release file to use with --github")
+	addCmd.Flags().StringVar(&addflags.name, "name", "", "Name in osmium.json for --url and --file, taken from the file name by default")
 
 	// make them mutually exclusive (Cobra built‑in)
 	addCmd.MarkFlagsMutuallyExclusive("mod", "plugin")
+	addCmd.MarkFlagsMutuallyExclusive("url", "file")
 	addCmd.MarkFlagsOneRequired("mod", "plugin")
 }
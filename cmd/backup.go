@@ -0,0 +1,212 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/limelamp/osmium/internal/shared"
+	"github.com/spf13/cobra"
+)
+
+type backupFlags struct {
+	flushWait   time.Duration
+	incremental bool
+	file        string
+	prune       bool
+	keepHourly  int
+	keepDaily   int
+	keepWeekly  int
+}
+
+var backupflags backupFlags
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Create, list, restore and prune world backups.",
+	Long: `Manages compressed backups of the server's world folders.
+
+The world folders are read from level-name in server.properties
+(world, world_nether and world_the_end by default).
+Examples:
+  osmium backup create
+  osmium backup create --incremental
+  osmium backup list
+  osmium backup restore world-20260101-040000
+  osmium backup verify
+  osmium backup prune --keep-daily 7`,
+}
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Archive the world folders.",
+	Long: `Archives the world folders into the backups folder.
+
+If the server is running, saving is turned off and the world is flushed
+through the console before archiving, then turned back on afterwards.
+
+With --incremental the worlds are chunked into a deduplicated store,
+so only data that changed since earlier snapshots takes up space.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if backupflags.incremental {
+			backup, stats, err := shared.CreateIncrementalBackup(backupflags.flushWait)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			fmt.Printf("✓ Snapshot created: %s (%d files, %d/%d chunks new, %s stored)\n",
+				backup.Name, stats.Files, stats.NewChunks, stats.Chunks, formatSize(stats.StoredBytes))
+		} else {
+			backup, err := shared.CreateBackup(backupflags.flushWait)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			fmt.Printf("✓ Backup created: %s (%s)\n", backup.Name, formatSize(backup.Size))
+		}
+
+		if backupflags.prune {
+			pruneBackups()
+		}
+	},
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List existing backups.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		backups, err := shared.ListBackups()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if len(backups) == 0 {
+			fmt.Println("No backups found.")
+			return
+		}
+
+		for _, backup := range backups {
+			kind := "full"
+			if backup.Incremental {
+				kind = "incremental"
+			}
+			fmt.Printf("%s  %-11s  %9s  %s\n", backup.CreatedAt.Format("2006-01-02 15:04:05"), kind, formatSize(backup.Size), backup.Name)
+		}
+	},
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore <backup>",
+	Short: "Replace the world folders with a backup.",
+	Long: `Replaces the world folders with the contents of a backup.
+
+Use --file to restore a single file from an incremental snapshot.
+Examples:
+  osmium backup restore world-20260101-040000.tar.gz
+  osmium backup restore world-20260101-040000 --file world/level.dat`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := shared.RestoreBackup(args[0], backupflags.file); err != nil {
+			fmt.Println(err)
+		}
+	},
+}
+
+var backupVerifyCmd = &cobra.Command{
+	Use:   "verify [snapshot]",
+	Short: "Check the integrity of incremental snapshots.",
+	Long: `Re-reads every chunk referenced by the incremental snapshots and checks
+it against its hash. Without arguments every snapshot is verified.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := ""
+		if len(args) == 1 {
+			name = args[0]
+		}
+
+		problems, err := shared.VerifyBackups(name)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		for _, problem := range problems {
+			fmt.Println("✗", problem)
+		}
+		if len(problems) > 0 {
+			fmt.Printf("Verification failed with %d problem(s)\n", len(problems))
+			os.Exit(1)
+		}
+		fmt.Println("✓ All snapshots verified")
+	},
+}
+
+var backupPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete backups outside the retention policy.",
+	Long: `Deletes backups that are not kept by the retention policy.
+
+The newest backup of each of the last N hours, days and weeks is kept.
+Example:
+  osmium backup prune --keep-hourly 24 --keep-daily 7 --keep-weekly 4`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		pruneBackups()
+	},
+}
+
+// pruneBackups applies the retention flags and reports what was removed
+func pruneBackups() {
+	removed, err := shared.PruneBackups(shared.RetentionPolicy{
+		Hourly: backupflags.keepHourly,
+		Daily:  backupflags.keepDaily,
+		Weekly: backupflags.keepWeekly,
+	})
+	for _, backup := range removed {
+		fmt.Println("Removed", backup.Name)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("✓ Pruned %d backup(s)\n", len(removed))
+}
+
+// formatSize renders a byte count in a human readable unit
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.AddCommand(backupCreateCmd, backupListCmd, backupRestoreCmd, backupVerifyCmd, backupPruneCmd)
+
+	backupCreateCmd.Flags().DurationVar(&backupflags.flushWait, "flush-wait", 5*time.Second, "Time to wait for 'save-all flush' before archiving")
+	backupCreateCmd.Flags().BoolVarP(&backupflags.incremental, "incremental", "i", false, "Store the backup in the deduplicated backup store")
+	backupCreateCmd.Flags().BoolVar(&backupflags.prune, "prune", false, "Apply the retention policy after creating the backup")
+	backupRestoreCmd.Flags().StringVarP(&backupflags.file, "file", "f", "", "Restore a single file from an incremental snapshot")
+
+	for _, c := range []*cobra.Command{backupCreateCmd, backupPruneCmd} {
+		c.Flags().IntVar(&backupflags.keepHourly, "keep-hourly", 24, "Number of hourly backups to keep")
+		c.Flags().IntVar(&backupflags.keepDaily, "keep-daily", 7, "Number of daily backups to keep")
+		c.Flags().IntVar(&backupflags.keepWeekly, "keep-weekly", 4, "Number of weekly backups to keep")
+	}
+}
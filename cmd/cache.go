@@ -0,0 +1,116 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/limelamp/osmium/internal/util"
+	"github.com/spf13/cobra"
+)
+
+type cacheFlags struct {
+	unusedDays int
+	all        bool
+}
+
+var cacheflags cacheFlags
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the shared download cache.",
+	Long: `Mods, plugins and server jars are downloaded once per machine into a cache in the
+app dir, keyed by their hashes, and hardlinked (or copied) into each server.
+
+With --offline, installs only use the cache and never touch the network.
+Installs also fall back to the cache on their own when Modrinth can't be reached.
+
+Examples:
+  osmium cache list
+  osmium cache size
+  osmium cache prune --unused-days 60
+  osmium install --offline`,
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the cached files, most recently used first.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := util.ListCache()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("The download cache is empty.")
+			return
+		}
+
+		for _, entry := range entries {
+			fmt.Printf("%s  %9s  %s  %s\n", entry.LastUsed.Format("2006-01-02 15:04:05"), formatSize(entry.Size), entry.SHA256[:12], entry.Name)
+		}
+	},
+}
+
+var cacheSizeCmd = &cobra.Command{
+	Use:   "size",
+	Short: "Show how much space the download cache uses.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := util.ListCache()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		total := int64(0)
+		for _, entry := range entries {
+			total += entry.Size
+		}
+
+		dir, _ := util.CacheDir()
+		fmt.Printf("%d files, %s in %s\n", len(entries), formatSize(total), dir)
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cached files that haven't been used recently.",
+	Long: `Removes files no server has installed within --unused-days, or everything with --all.
+Servers keep their own hardlinks or copies, pruning never breaks an installed server.
+
+Examples:
+  osmium cache prune
+  osmium cache prune --unused-days 7
+  osmium cache prune --all`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		olderThan := time.Duration(cacheflags.unusedDays) * 24 * time.Hour
+		if cacheflags.all {
+			olderThan = 0
+		} else if cacheflags.unusedDays < 1 {
+			fmt.Println("--unused-days must be at least 1, use --all to clear the cache")
+			return
+		}
+
+		removed, freed, err := util.PruneCache(olderThan)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("✓ Removed %d files, freed %s\n", removed, formatSize(freed))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheListCmd, cacheSizeCmd, cachePruneCmd)
+
+	cachePruneCmd.Flags().IntVar(&cacheflags.unusedDays, "unused-days", 30, "Remove files not used for this many days")
+	cachePruneCmd.Flags().BoolVar(&cacheflags.all, "all", false, "Remove every cached file")
+}
@@ -0,0 +1,79 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+	"github.com/spf13/cobra"
+)
+
+type curseforgeFlags struct {
+	clear bool
+}
+
+var curseforgeflags curseforgeFlags
+
+// curseforgeCmd represents the curseforge command
+var curseforgeCmd = &cobra.Command{
+	Use:   "curseforge",
+	Short: "Configure the CurseForge project source.",
+	Long: `CurseForge has many Forge and NeoForge mods that aren't on Modrinth. Its API needs a key,
+which you can get from the CurseForge console (console.curseforge.com). The key is stored
+in the app config, OSMIUM_CURSEFORGE_API_KEY overrides it.
+
+With a key, 'osmium track' also matches jars against CurseForge. Some authors don't allow
+third-party downloads, their files are tracked but have to be downloaded by hand, and
+'osmium install' lists them.
+
+Examples:
+  osmium curseforge key '$2a$10$...'
+  osmium curseforge key --clear
+  osmium add --mod --source curseforge jei`,
+}
+
+var curseforgeKeyCmd = &cobra.Command{
+	Use:   "key [api-key]",
+	Short: "Set the CurseForge API key, or show whether one is set.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Println("Error reading the app config:", err)
+			return
+		}
+
+		switch {
+		case curseforgeflags.clear:
+			cfg.CurseForgeAPIKey = ""
+		case len(args) == 1:
+			cfg.CurseForgeAPIKey = args[0]
+		case cfg.CurseForgeAPIKey == "":
+			fmt.Println("No CurseForge API key set.")
+			return
+		default:
+			fmt.Println("A CurseForge API key is set.")
+			return
+		}
+
+		if err := config.SaveConfig(cfg); err != nil {
+			fmt.Println("Error saving the app config:", err)
+			return
+		}
+
+		if curseforgeflags.clear {
+			fmt.Println("✓ CurseForge API key removed")
+		} else {
+			fmt.Println("✓ CurseForge API key saved")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(curseforgeCmd)
+	curseforgeCmd.AddCommand(curseforgeKeyCmd)
+
+	curseforgeKeyCmd.Flags().BoolVar(&curseforgeflags.clear, "clear", false, "Remove the stored API key")
+}
@@ -5,33 +5,50 @@ package cmd
 
 import (
 	"fmt"
-	"net"
+	"os"
 	"strings"
 
+	"github.com/limelamp/osmium/internal/shared"
+	"github.com/limelamp/osmium/internal/tui/storage"
 	"github.com/spf13/cobra"
 )
 
+type execFlags struct {
+	server string
+}
+
+var execflags execFlags
+
 // execCmd represents the exec command
 var execCmd = &cobra.Command{
 	Use:   "exec [minecraft command]",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
+	Short: "Send a command to the running server's console.",
+	Long: `Sends a console command to the server started with 'osmium run' or from the TUI,
+through the console socket in its folder.
+
+The server is picked with --server, or from the current directory.
+Examples:
+  osmium exec say Restarting in 5 minutes
+  osmium exec --server 1a2b3c stop`,
+	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-
-		conn, err := net.Dial("tcp", "127.0.0.1:59072")
-		if err != nil {
-			fmt.Println("Server is not running (couldn't connect to socket).")
-			return
+		if execflags.server != "" {
+			server, err := resolveServer(storage.NewServerStore(), execflags.server)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if err := os.Chdir(server.Path); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
 		}
-		defer conn.Close()
 
 		// Send the 'message' to the background daemon a.k.a. the server
-		fmt.Fprintln(conn, strings.Join(args, " "))
+		if err := shared.SendConsoleCommand(strings.Join(args, " ")); err != nil {
+			fmt.Println("Server is not running (couldn't connect to socket).")
+			os.Exit(1)
+		}
 		fmt.Println(strings.Join(args, " "))
 	},
 }
@@ -39,4 +56,5 @@ to quickly create a Cobra application.`,
 func init() {
 	rootCmd.AddCommand(execCmd)
 
+	execCmd.Flags().StringVarP(&execflags.server, "server", "s", "", "ID of the server (defaults to the current directory)")
 }
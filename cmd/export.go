@@ -0,0 +1,159 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/limelamp/osmium/internal/shared"
+	"github.com/limelamp/osmium/internal/tui/storage"
+	"github.com/spf13/cobra"
+)
+
+type exportFlags struct {
+	server        string
+	force         bool
+	osmiumVersion string
+	output        string
+	name          string
+	packVersion   string
+	overrides     []string
+	loaderVersion string
+}
+
+var exportflags exportFlags
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a server for other environments.",
+}
+
+var exportDockerCmd = &cobra.Command{
+	Use:   "docker",
+	Short: "Generate a Dockerfile and compose file for a server.",
+	Long: `Writes a Dockerfile, docker-compose.yml and .dockerignore into the server folder.
+
+The image uses the Temurin Java version the Minecraft version needs, fetches the
+mods and plugins listed in osmium.json with 'osmium install' at build time and runs
+the server under 'osmium run'. Worlds, configs, logs and backups are kept in volumes,
+the port comes from server.properties and the memory limit from the server's memory.
+
+The server is picked with --server, or from the current directory.
+Examples:
+  osmium export docker
+  osmium export docker --server 1a2b3c --force
+  docker compose up -d --build`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		server, err := resolveServer(storage.NewServerStore(), exportflags.server)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if err := os.Chdir(server.Path); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		written, err := shared.ExportDocker(server, exportflags.osmiumVersion, exportflags.force)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		for _, name := range written {
+			fmt.Printf("✓ Wrote %s\n", name)
+		}
+		fmt.Println("\nBuild and start it with: docker compose up -d --build")
+		fmt.Println("Send console commands with: docker compose exec <service> osmium exec \"say hi\"")
+	},
+}
+
+var exportMrpackCmd = &cobra.Command{
+	Use:   "mrpack",
+	Short: "Build a Modrinth modpack matching a server's mods.",
+	Long: `Builds a .mrpack from the mods tracked in osmium.json, so players can install a client
+with exactly the server's mods in the Modrinth app, Prism Launcher or any other launcher that
+reads Modrinth modpacks.
+
+Mods are referenced by their download URLs, the files in mods/ are checked against the
+SHA1s in osmium.json first. Modpacks may only download from Modrinth and GitHub, so CurseForge
+mods and jars added from a URL or a file are skipped with a warning. Folders passed with --overrides (config by default) are bundled
+as overrides. The loader version is read from the server's libraries folder, or set with --loader-version.
+
+The server is picked with --server, or from the current directory.
+Examples:
+  osmium export mrpack
+  osmium export mrpack --name "My SMP" --pack-version 1.2.0
+  osmium export mrpack --overrides config,defaultconfigs,resourcepacks -o ~/smp.mrpack`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		server, err := resolveServer(storage.NewServerStore(), exportflags.server)
+		if err != nil {
+			// Only osmium.json is needed, so unregistered server folders can be exported too
+			cwd, cwdErr := os.Getwd()
+			if exportflags.server != "" || cwdErr != nil {
+				fmt.Println(err)
+				return
+			}
+			server = storage.Server{Name: filepath.Base(cwd), Path: cwd}
+		}
+
+		name := exportflags.name
+		if name == "" {
+			name = server.Name
+		}
+
+		// Resolve the output before switching to the server folder, so relative paths are where the user expects
+		output := exportflags.output
+		if output == "" {
+			slug := strings.Trim(serverIDUnsafe.ReplaceAllString(strings.ToLower(name), "-"), "-")
+			output = filepath.Join(server.Path, slug+"-"+exportflags.packVersion+".mrpack")
+		}
+		if output, err = filepath.Abs(output); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if err := os.Chdir(server.Path); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		count, err := shared.ExportMrpack(shared.MrpackExportOptions{
+			Name:          name,
+			Version:       exportflags.packVersion,
+			Output:        output,
+			Overrides:     exportflags.overrides,
+			LoaderVersion: exportflags.loaderVersion,
+		})
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✓ Wrote %s with %d mods\n", output, count)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportDockerCmd, exportMrpackCmd)
+
+	exportDockerCmd.Flags().StringVarP(&exportflags.server, "server", "s", "", "ID of the server (defaults to the current directory)")
+	exportDockerCmd.Flags().BoolVarP(&exportflags.force, "force", "f", false, "Overwrite an existing Dockerfile and compose file")
+	exportDockerCmd.Flags().StringVar(&exportflags.osmiumVersion, "osmium-version", "latest", "Osmium version installed in the image")
+
+	exportMrpackCmd.Flags().StringVarP(&exportflags.server, "server", "s", "", "ID of the server (defaults to the current directory)")
+	exportMrpackCmd.Flags().StringVarP(&exportflags.output, "output", "o", "", "File to write (defaults to <name>-<version>.mrpack in the server folder)")
+	exportMrpackCmd.Flags().StringVar(&exportflags.name, "name", "", "Name of the modpack (defaults to the server name)")
+	exportMrpackCmd.Flags().StringVar(&exportflags.packVersion, "pack-version", "1.0.0", "Version of the modpack")
+	exportMrpackCmd.Flags().StringSliceVar(&exportflags.overrides, "overrides", []string{"config"}, "Server folders bundled as overrides")
+	exportMrpackCmd.Flags().StringVar(&exportflags.loaderVersion, "loader-version", "", "Loader version (detected from libraries/ when empty)")
+}
@@ -0,0 +1,94 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/limelamp/osmium/internal/shared"
+	"github.com/limelamp/osmium/internal/tui/storage"
+	"github.com/spf13/cobra"
+)
+
+type importFlags struct {
+	memory     string
+	noRegister bool
+}
+
+var importflags importFlags
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Create a server from an existing modpack.",
+}
+
+var importMrpackCmd = &cobra.Command{
+	Use:   "mrpack <file>",
+	Short: "Set up a server in the current directory from a Modrinth modpack.",
+	Long: `Reads modrinth.index.json from a .mrpack file and sets up a server in the current directory.
+
+Files the pack marks as unsupported on servers (client-only mods) are skipped,
+everything else is downloaded and checked against the pack's hashes.
+overrides/ and server-overrides/ are copied in, osmium.json gets the pack's loader
+and Minecraft version, the server jar is installed and the mods are tracked.
+The server is registered in servers.json unless --no-register is given.
+
+Example:
+  mkdir my-pack && cd my-pack
+  osmium import mrpack ~/Downloads/MyPack-1.2.0.mrpack`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		packPath, err := filepath.Abs(args[0])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		result, err := shared.ImportMrpack(packPath)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("\n✓ Imported %s: %d files downloaded, %d client-only files skipped, %d override files\n",
+			result.Name, result.Downloaded, result.Skipped, result.Overrides)
+
+		if importflags.noRegister {
+			return
+		}
+
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		store := storage.NewServerStore()
+		server := storage.Server{
+			ID:      newServerID(store, result.Name),
+			Name:    result.Name,
+			Path:    dir,
+			Version: result.Version,
+			Type:    strings.ToLower(result.Loader),
+			Memory:  importflags.memory,
+		}
+		if err := store.Add(server); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("✓ Registered as %s\n", server.ID)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.AddCommand(importMrpackCmd)
+
+	importMrpackCmd.Flags().StringVarP(&importflags.memory, "memory", "m", "4G", "Memory for the registered server")
+	importMrpackCmd.Flags().BoolVar(&importflags.noRegister, "no-register", false, "Don't add the server to servers.json")
+}
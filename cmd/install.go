@@ -5,14 +5,17 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/limelamp/osmium/internal/shared"
+	"github.com/limelamp/osmium/internal/tui/config"
 	"github.com/spf13/cobra"
 )
 
 type installFlags struct {
 	modFlag    bool
 	pluginFlag bool
+	frozen     bool
 }
 
 var installflags installFlags
@@ -23,10 +26,26 @@ var installCmd = &cobra.Command{
 	Short: "Install all projects from osmium.json.",
 	Long: `Installs all tracked mods and plugins listed in osmium.json.
 
-Example:
-  osmium install`,
+With --frozen, exactly the versions in osmium.lock.json are installed instead.
+Nothing is resolved against Modrinth, and the install fails without changing anything
+if the lock disagrees with osmium.json, a file doesn't match its locked hash or an
+untracked jar is in the way. Use it to make staging and production identical.
+
+Examples:
+  osmium install
+  osmium install --frozen`,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
+		if installflags.frozen {
+			installed, err := shared.InstallFrozen()
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			fmt.Printf("✓ Installed %d projects from %s\n", installed, config.LockFileName)
+			return
+		}
+
 		if err := shared.InstallProjectsFromConfig(); err != nil {
 			fmt.Println(err)
 			return
@@ -36,4 +55,6 @@ Example:
 
 func init() {
 	rootCmd.AddCommand(installCmd)
+
+	installCmd.Flags().BoolVar(&installflags.frozen, "frozen", false, "Install exactly the versions in osmium.lock.json, failing on any mismatch")
 }
@@ -0,0 +1,233 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/limelamp/osmium/internal/shared"
+	"github.com/limelamp/osmium/internal/tui/config"
+	"github.com/limelamp/osmium/internal/tui/storage"
+	"github.com/limelamp/osmium/internal/util"
+	"github.com/spf13/cobra"
+)
+
+type javaFlags struct {
+	server string
+	clear  bool
+	apiURL string
+	noPin  bool
+}
+
+var javaflags javaFlags
+
+// javaCmd represents the java command
+var javaCmd = &cobra.Command{
+	Use:   "java",
+	Short: "Find Java installations and pick the one a server runs with.",
+	Long: `Discovers installed Java runtimes (PATH, JAVA_HOME, /usr/lib/jvm, sdkman, ...)
+and lets each server pin the java binary it's launched with.
+
+Minecraft needs Java 8 before 1.17, Java 16 for 1.17, Java 17 up to 1.20.4
+and Java 21 from 1.20.5. The server is picked with --server, or from the current directory.
+Examples:
+  osmium java list
+  osmium java check
+  osmium java use 21
+  osmium java use /usr/lib/jvm/java-17-openjdk/bin/java
+  osmium java use --clear
+  osmium java install 21`,
+}
+
+var javaListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the Java runtimes found on this machine.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runtimes := util.DiscoverJava()
+		if len(runtimes) == 0 {
+			fmt.Println("No Java installations found.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "MAJOR\tVERSION\tSOURCE\tPATH")
+		for _, rt := range runtimes {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", rt.Major, rt.Version, rt.Source, rt.Path)
+		}
+		w.Flush()
+	},
+}
+
+var javaCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check that the server's java matches its Minecraft version.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		osmiumConf, err := config.ReadConfig()
+		if err != nil {
+			fmt.Printf("failed to read osmium.json: %v\n", err)
+			return
+		}
+
+		required := util.RequiredJavaMajor(osmiumConf.Version)
+		fmt.Printf("Minecraft %s needs Java %d or newer\n", osmiumConf.Version, required)
+
+		if javaPath := shared.GetRunOptions().JavaPath; javaPath != "" {
+			fmt.Printf("Pinned java: %s\n", javaPath)
+		} else {
+			fmt.Println("Using java from PATH")
+		}
+
+		if warning := shared.CheckServerJava(); warning != "" {
+			fmt.Printf("⚠ %s\n", warning)
+			if rt, err := util.FindJavaForMajor(required); err == nil {
+				fmt.Printf("  Java %d is installed at %s, run 'osmium java use %d'\n", required, rt.Path, required)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Println("✓ Java is compatible")
+	},
+}
+
+var javaUseCmd = &cobra.Command{
+	Use:   "use <major|path>",
+	Short: "Pin the java binary the server is launched with.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if javaflags.clear {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		store := storage.NewServerStore()
+		server, err := resolveServer(store, javaflags.server)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		var rt util.JavaRuntime
+		if !javaflags.clear {
+			// A bare number picks a discovered runtime of that major version
+			if major, err := strconv.Atoi(args[0]); err == nil {
+				rt, err = util.FindJavaForMajor(major)
+				if err != nil {
+					fmt.Println(err)
+					return
+				}
+			} else {
+				javaPath, err := filepath.Abs(args[0])
+				if err != nil {
+					fmt.Println(err)
+					return
+				}
+				if rt, err = util.GetJavaRuntime(javaPath); err != nil {
+					fmt.Println(err)
+					return
+				}
+			}
+		}
+
+		err = store.Update(server.ID, func(s *storage.Server) error {
+			s.JavaPath = rt.Path
+			return nil
+		})
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if javaflags.clear {
+			fmt.Printf("✓ %s uses java from PATH again\n", server.Name)
+			return
+		}
+
+		fmt.Printf("✓ %s now runs with Java %s (%s)\n", server.Name, rt.Version, rt.Path)
+		if server.Version != "" {
+			if warning := util.CheckJavaCompatibility(rt, server.Version); warning != "" {
+				fmt.Printf("⚠ %s\n", warning)
+			}
+		}
+	},
+}
+
+var javaInstallCmd = &cobra.Command{
+	Use:   "install <major>",
+	Short: "Download a Temurin JDK into Osmium's app folder.",
+	Long: `Downloads the latest Eclipse Temurin JDK of a major version for this machine from Adoptium,
+verifies its checksum and keeps it in Osmium's app folder.
+
+Registered servers that need this Java version and don't have a working one pinned
+are switched to the new JDK, unless --no-pin is given.
+Examples:
+  osmium java install 21
+  osmium java install 8 --no-pin`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		major, err := strconv.Atoi(args[0])
+		if err != nil || major <= 0 {
+			fmt.Printf("invalid Java version %q, expected a major version like 17 or 21\n", args[0])
+			return
+		}
+
+		if javaflags.apiURL != "" {
+			util.AdoptiumAPIURL = javaflags.apiURL
+		}
+
+		jdk, err := util.InstallJDK(major)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Installed %s at %s\n", jdk.Release, jdk.JavaPath)
+
+		if javaflags.noPin {
+			return
+		}
+
+		// Pin servers that need this major version and can't run with what they have
+		store := storage.NewServerStore()
+		servers, err := store.LoadAll()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		for _, server := range servers {
+			if server.Version == "" || util.RequiredJavaMajor(server.Version) != major {
+				continue
+			}
+			if server.JavaPath != "" {
+				if rt, err := util.GetJavaRuntime(server.JavaPath); err == nil && util.CheckJavaCompatibility(rt, server.Version) == "" {
+					continue
+				}
+			}
+
+			err := store.Update(server.ID, func(s *storage.Server) error {
+				s.JavaPath = jdk.JavaPath
+				return nil
+			})
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Printf("✓ %s now runs with %s\n", server.Name, jdk.Release)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(javaCmd)
+	javaCmd.AddCommand(javaListCmd, javaCheckCmd, javaUseCmd, javaInstallCmd)
+
+	javaCmd.PersistentFlags().StringVarP(&javaflags.server, "server", "s", "", "ID of the server (defaults to the current directory)")
+	javaUseCmd.Flags().BoolVar(&javaflags.clear, "clear", false, "Unpin and use java from PATH")
+	javaInstallCmd.Flags().BoolVar(&javaflags.noPin, "no-pin", false, "Only install, don't switch servers to the new JDK")
+	javaInstallCmd.Flags().StringVar(&javaflags.apiURL, "api-url", "", "Base URL of the Adoptium API or a mirror of it")
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/limelamp/osmium/internal/tui/storage"
+	"github.com/limelamp/osmium/internal/util"
+	"github.com/spf13/cobra"
+)
+
+type jvmFlags struct {
+	server     string
+	profile    string
+	memory     string
+	flags      []string
+	clearFlags bool
+}
+
+var jvmflags jvmFlags
+
+// jvmCmd represents the jvm command
+var jvmCmd = &cobra.Command{
+	Use:   "jvm",
+	Short: "Show or change the JVM settings of a server.",
+	Long: `Shows the JVM arguments the server is launched with, or changes them.
+
+The settings are stored with the server entry in servers.json and used by the TUI,
+'osmium run' and generated run scripts. Forge and NeoForge get them through user_jvm_args.txt.
+
+Profiles:
+  aikar    Aikar's tuned G1 flags (default)
+  zgc      ZGC, low pauses, needs Java 17+
+  minimal  only -Xms/-Xmx
+
+The server is picked with --server, or from the current directory.
+Examples:
+  osmium jvm
+  osmium jvm --profile zgc --memory 8G
+  osmium jvm --flag -Dfile.encoding=UTF-8 --flag -XX:+UseStringDeduplication
+  osmium jvm --clear-flags`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := storage.NewServerStore()
+		server, err := resolveServer(store, jvmflags.server)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		changed := cmd.Flags().Changed("profile") || cmd.Flags().Changed("memory") || cmd.Flags().Changed("flag") || jvmflags.clearFlags
+		if changed {
+			// Validate before saving
+			if jvmflags.profile != "" && !slices.Contains(util.JVMProfiles, jvmflags.profile) {
+				fmt.Printf("unknown JVM profile %q, expected one of %s\n", jvmflags.profile, strings.Join(util.JVMProfiles, ", "))
+				return
+			}
+			if cmd.Flags().Changed("memory") {
+				if jvmflags.memory, err = util.NormalizeMemory(jvmflags.memory); err != nil {
+					fmt.Println(err)
+					return
+				}
+			}
+
+			err = store.Update(server.ID, func(s *storage.Server) error {
+				if cmd.Flags().Changed("profile") {
+					s.JVMProfile = jvmflags.profile
+				}
+				if cmd.Flags().Changed("memory") {
+					s.Memory = jvmflags.memory
+				}
+				if jvmflags.clearFlags {
+					s.JVMFlags = nil
+				}
+				s.JVMFlags = append(s.JVMFlags, jvmflags.flags...)
+				server = *s
+				return nil
+			})
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			fmt.Println("✓ JVM settings updated")
+		}
+
+		profile := server.JVMProfile
+		if profile == "" {
+			profile = util.DefaultJVMProfile
+		}
+
+		jvmArgs, err := util.JVMArgs(util.RunOptions{
+			Memory:  server.Memory,
+			Profile: server.JVMProfile,
+			Flags:   server.JVMFlags,
+		})
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		fmt.Printf("Server:  %s\n", server.Name)
+		fmt.Printf("Profile: %s\n", profile)
+		fmt.Printf("Memory:  %s\n", jvmArgs[1][len("-Xmx"):])
+		fmt.Printf("Extra:   %s\n", strings.Join(server.JVMFlags, " "))
+		fmt.Println("\nJVM arguments:")
+		for _, arg := range jvmArgs {
+			fmt.Println("  " + arg)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(jvmCmd)
+
+	jvmCmd.Flags().StringVarP(&jvmflags.server, "server", "s", "", "ID of the server (defaults to the current directory)")
+	jvmCmd.Flags().StringVarP(&jvmflags.profile, "profile", "p", "", "JVM profile: aikar, zgc or minimal")
+	jvmCmd.Flags().StringVarP(&jvmflags.memory, "memory", "m", "", "Heap size, e.g. 4G or 4096M")
+	jvmCmd.Flags().StringArrayVar(&jvmflags.flags, "flag", nil, "Extra JVM flag to append (repeatable)")
+	jvmCmd.Flags().BoolVar(&jvmflags.clearFlags, "clear-flags", false, "Remove all extra JVM flags")
+}
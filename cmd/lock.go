@@ -0,0 +1,41 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/limelamp/osmium/internal/shared"
+	"github.com/limelamp/osmium/internal/tui/config"
+	"github.com/spf13/cobra"
+)
+
+// lockCmd represents the lock command
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Write osmium.lock.json from the versions in osmium.json.",
+	Long: `Records the exact Modrinth version, download URL, hashes and required
+dependencies of every tracked project in osmium.lock.json, without upgrading anything.
+
+The lock is kept up to date by add, update, track, remove and migrate,
+this command (re)creates it for servers set up before it existed.
+Commit it next to osmium.json and install with 'osmium install --frozen'.
+
+Example:
+  osmium lock`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		locked, err := shared.LockProjects()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Locked %d projects in %s\n", locked, config.LockFileName)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lockCmd)
+}
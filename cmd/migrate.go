@@ -4,16 +4,21 @@ Copyright © 2026 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/limelamp/osmium/internal/shared"
 	"github.com/spf13/cobra"
 )
 
 type migrateFlags struct {
-	loader  string
-	version string
+	loader   string
+	version  string
+	dryRun   bool
+	jsonFlag bool
 }
 
 var migrateflags migrateFlags
@@ -25,16 +30,47 @@ var migrateCmd = &cobra.Command{
 	Long: `Migrate your Minecraft server to a different mod loader or version.
 
 This command will:
+  - Take a rollback point (restored automatically on failure, or with 'osmium rollback')
   - Replace the server.jar with the new loader/version
   - Migrate existing mods/plugins to compatible versions
   - Keep world data, configs, and other files intact
   - Move incompatible mods/plugins to a backup folder
 
+Use --dry-run to only print which projects would be upgraded, which have
+no compatible build and which dependencies would be added.
+
 Example:
   osmium migrate -l Paper -v 1.21.1
-  osmium migrate -l Fabric -v 1.20.4`,
-	Args: cobra.NoArgs,
+  osmium migrate -l Fabric -v 1.20.4
+  osmium migrate -l Fabric -v 1.21.1 --dry-run --json`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if migrateflags.jsonFlag && !migrateflags.dryRun {
+			return fmt.Errorf("--json only works with --dry-run")
+		}
+		return cobra.NoArgs(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
+		if migrateflags.dryRun {
+			plan, err := shared.PlanMigration(migrateflags.loader, migrateflags.version)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if migrateflags.jsonFlag {
+				data, err := json.MarshalIndent(plan, "", "  ")
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Println(string(data))
+				return
+			}
+
+			printMigrationPlan(plan)
+			return
+		}
+
 		if err := shared.MigrateServer(migrateflags.loader, migrateflags.version); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
@@ -42,10 +78,59 @@ Example:
 	},
 }
 
+// printMigrationPlan renders a dry-run report as tables
+func printMigrationPlan(plan shared.MigrationPlan) {
+	fmt.Printf("Current: %s %s\n", plan.FromLoader, plan.FromVersion)
+	fmt.Printf("Target:  %s %s\n", plan.ToLoader, plan.ToVersion)
+	if plan.ReplaceServerJar {
+		fmt.Println("server.jar will be replaced")
+	} else {
+		fmt.Println("server.jar unchanged")
+	}
+
+	fmt.Println("\n--- Projects ---")
+	if len(plan.Projects) == 0 {
+		fmt.Println("No tracked projects.")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PROJECT\tFOLDER\tSTATUS\tCHANGE")
+		for _, entry := range plan.Projects {
+			change := ""
+			switch entry.Status {
+			case shared.PlanUpgrade:
+				change = fmt.Sprintf("%s → %s", entry.CurrentVersion, entry.NewVersion)
+			case shared.PlanUnchanged:
+				change = entry.CurrentVersion
+			case shared.PlanIncompatible:
+				change = "moved to migration_backup"
+			case shared.PlanError:
+				change = entry.Error
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Slug, entry.Folder, entry.Status, change)
+		}
+		w.Flush()
+	}
+
+	fmt.Println("\n--- Dependencies to add ---")
+	if len(plan.AddedDependencies) == 0 {
+		fmt.Println("None.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tTYPE\tFOLDER\tREQUIRED BY")
+	for _, dep := range plan.AddedDependencies {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", dep.Slug, dep.Type, dep.Folder, strings.Join(dep.RequiredBy, ", "))
+	}
+	w.Flush()
+}
+
 func init() {
 	rootCmd.AddCommand(migrateCmd)
 	migrateCmd.Flags().StringVarP(&migrateflags.loader, "loader", "l", "", "Minecraft mod or plugin loader")
 	migrateCmd.Flags().StringVarP(&migrateflags.version, "version", "v", "", "Minecraft version")
+	migrateCmd.Flags().BoolVar(&migrateflags.dryRun, "dry-run", false, "Only report what the migration would do")
+	migrateCmd.Flags().BoolVar(&migrateflags.jsonFlag, "json", false, "Print the dry-run report as JSON, needs --dry-run")
 	migrateCmd.MarkFlagRequired("loader")
 	migrateCmd.MarkFlagRequired("version")
 }
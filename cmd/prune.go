@@ -0,0 +1,86 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/limelamp/osmium/internal/shared"
+	"github.com/spf13/cobra"
+)
+
+type pruneFlags struct {
+	yes       bool
+	libraries bool
+}
+
+var pruneflags pruneFlags
+
+// pruneCmd represents the prune command
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove dependencies no project needs anymore.",
+	Long: `Finds the dependencies left behind by removed projects and offers to remove them:
+projects that were pulled in as dependencies of projects that are gone, and jars in
+optional_mods/optional_plugins nothing depends on.
+
+--libraries also looks for Modrinth libraries no tracked project requires, for installs from
+before osmium recorded which project pulled in a dependency. That's a guess: players or
+untracked plugins may still use them, so check the list before confirming.
+
+Asks before removing anything unless --yes is given.
+Examples:
+  osmium prune
+  osmium prune --yes
+  osmium prune --libraries`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		orphans, err := shared.FindOrphans(pruneflags.libraries)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if len(orphans) == 0 {
+			fmt.Println("✓ No orphaned dependencies")
+			return
+		}
+
+		removeOrphans(orphans, pruneflags.yes)
+	},
+}
+
+// removeOrphans lists the orphans and removes them once confirmed
+func removeOrphans(orphans []shared.Orphan, yes bool) {
+	fmt.Println("These dependencies aren't needed anymore:")
+	for _, orphan := range orphans {
+		fmt.Printf("  %s (%s, %s)\n", orphan.Name(), orphan.Path(), orphan.Reason)
+	}
+
+	if !yes && !confirm("Remove them?") {
+		fmt.Println("Kept them, run 'osmium prune' to remove them later")
+		return
+	}
+
+	if err := shared.RemoveOrphans(orphans); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// confirm asks a yes/no question on the terminal, anything but yes is no
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+
+	pruneCmd.Flags().BoolVarP(&pruneflags.yes, "yes", "y", false, "Remove without asking")
+	pruneCmd.Flags().BoolVar(&pruneflags.libraries, "libraries", false, "Also list Modrinth libraries no tracked project requires")
+}
@@ -13,6 +13,7 @@ import (
 type removeFlags struct {
 	modFlag    bool
 	pluginFlag bool
+	yes        bool
 }
 
 var removeflags removeFlags
@@ -23,10 +24,12 @@ var removeCmd = &cobra.Command{
 	Short: "Remove one or more tracked projects.",
 	Long: `Removes one or more tracked projects and deletes their files.
 
-Use --mod for mods or --plugin for plugins.
+Use --mod for mods or --plugin for plugins. Dependencies no remaining project needs are
+listed afterwards and removed too once confirmed, or right away with --yes.
 Examples:
   osmium remove --mod sodium
-  osmium remove --plugin luckperms`,
+  osmium remove --plugin luckperms
+  osmium remove --mod sodium --yes`,
 	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		var projectType string
@@ -41,10 +44,25 @@ Examples:
 			return
 		}
 
+		removed := 0
 		for _, projectID := range args {
 			if err := shared.RemoveProjectByID(projectID, projectType); err != nil {
 				fmt.Println(err)
+				continue
 			}
+			removed++
+		}
+		if removed == 0 {
+			return
+		}
+
+		orphans, err := shared.FindOrphans(false)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if len(orphans) > 0 {
+			removeOrphans(orphans, removeflags.yes)
 		}
 	},
 }
@@ -54,6 +72,7 @@ func init() {
 
 	removeCmd.Flags().BoolVarP(&removeflags.modFlag, "mod", "m", false, "Download as mod")
 	removeCmd.Flags().BoolVarP(&removeflags.pluginFlag, "plugin", "p", false, "Download as plugin")
+	removeCmd.Flags().BoolVarP(&removeflags.yes, "yes", "y", false, "Remove dependencies no project needs anymore without asking")
 
 	// make them mutually exclusive (Cobra built‑in)
 	removeCmd.MarkFlagsMutuallyExclusive("mod", "plugin")
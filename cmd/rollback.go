@@ -0,0 +1,57 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/limelamp/osmium/internal/shared"
+	"github.com/spf13/cobra"
+)
+
+var rollbackListFlag bool
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Restore the state before the last migrate or update.",
+	Long: `Restores osmium.json, the server jar and all mod/plugin jars from the
+rollback point taken before the last migrate or update.
+
+Running it again steps further back through older rollback points.
+Examples:
+  osmium rollback
+  osmium rollback --list`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if rollbackListFlag {
+			points, err := shared.ListRollbackPoints()
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			if len(points) == 0 {
+				fmt.Println("No rollback points found.")
+				return
+			}
+			for _, point := range points {
+				fmt.Printf("%s  %s\n", point.CreatedAt.Format("2006-01-02 15:04:05"), point.Operation)
+			}
+			return
+		}
+
+		point, err := shared.RollbackLast()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("✓ Rolled back to the state before %s (%s)\n", point.Operation, point.CreatedAt.Format("2006-01-02 15:04:05"))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+
+	rollbackCmd.Flags().BoolVarP(&rollbackListFlag, "list", "l", false, "List the available rollback points")
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"os"
 
+	"github.com/limelamp/osmium/internal/util"
 	"github.com/spf13/cobra"
 )
 
@@ -29,4 +30,5 @@ func Execute() {
 
 func init() {
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	rootCmd.PersistentFlags().BoolVar(&util.Offline, "offline", false, "Install from the download cache only, without using the network")
 }
@@ -0,0 +1,95 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/limelamp/osmium/internal/shared"
+	"github.com/limelamp/osmium/internal/tui/config"
+	"github.com/limelamp/osmium/internal/tui/storage"
+	"github.com/spf13/cobra"
+)
+
+var runStopTimeout time.Duration
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the server in the foreground under Osmium's supervisor.",
+	Long: `Starts the Minecraft server in the current directory and keeps it supervised.
+
+Console input is read from the terminal and from 'osmium exec'.
+If the directory belongs to a registered server, its scheduled tasks run
+while the server is up (see 'osmium schedule').
+Interrupting the supervisor sends 'stop' so the world is saved.
+
+Example:
+  osmium run`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		osmiumConf, err := config.ReadConfig()
+		if err != nil {
+			fmt.Printf("failed to read osmium.json: %v\n", err)
+			os.Exit(1)
+		}
+
+		supervisor := shared.NewSupervisor(osmiumConf.Loader, os.Stdout)
+		if err := supervisor.Start(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		// Console input from the socket and the terminal
+		go shared.StartBasicSocketServer(supervisor)
+		go func() {
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				fmt.Fprintln(supervisor, scanner.Text())
+			}
+		}()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		store := storage.NewServerStore()
+		if dir, err := os.Getwd(); err == nil {
+			if server, err := store.FindByPath(dir); err == nil {
+				go shared.RunScheduler(ctx, store, server.ID, shared.SchedulerHooks{
+					Restart: func(whileStopped func() error) error {
+						return supervisor.RestartWith(runStopTimeout, whileStopped)
+					},
+					Send: supervisor.Send,
+				})
+			}
+		}
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			fmt.Println("Stopping server...")
+			if err := supervisor.Stop(runStopTimeout); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		if err := supervisor.Wait(); err != nil {
+			fmt.Printf("Server exited: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().DurationVar(&runStopTimeout, "stop-timeout", 60*time.Second, "How long to wait for a graceful stop before killing the server")
+}
@@ -0,0 +1,234 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/limelamp/osmium/internal/shared"
+	"github.com/limelamp/osmium/internal/tui/storage"
+	"github.com/spf13/cobra"
+)
+
+type scheduleFlags struct {
+	server   string
+	cron     string
+	action   string
+	argument string
+}
+
+var scheduleflags scheduleFlags
+
+// scheduleCmd represents the schedule command
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Manage scheduled tasks of a server.",
+	Long: `Manages cron-style tasks stored with the server entry in servers.json.
+
+Tasks run while the server is started with 'osmium run'. Servers started from the TUI
+don't run their tasks, use 'osmium schedule run' next to them.
+Actions: command (console command), restart, backup ("incremental" argument
+uses the backup store) and update (stops the server, updates all tracked mods/plugins
+and starts it again).
+
+The server is picked with --server, or from the current directory.
+Examples:
+  osmium schedule list
+  osmium schedule add --cron "0 4 * * *" --action restart
+  osmium schedule add --cron "55 3 * * *" --action command --arg "say Restarting in 5 minutes"
+  osmium schedule add --cron "@hourly" --action backup --arg incremental
+  osmium schedule remove task-18c0f2a1`,
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List scheduled tasks.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		server, err := resolveServer(storage.NewServerStore(), scheduleflags.server)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if len(server.Tasks) == 0 {
+			fmt.Printf("No scheduled tasks for %s.\n", server.Name)
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCRON\tACTION\tARGUMENT\tENABLED\tLAST RUN\tRESULT\tNEXT RUN")
+		for _, task := range server.Tasks {
+			nextRun := task.NextRun
+			if !task.Enabled {
+				nextRun = time.Time{}
+			} else if nextRun.IsZero() {
+				nextRun = shared.NextTaskRun(task, time.Now())
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%s\t%s\t%s\n",
+				task.ID, task.Cron, task.Action, task.Argument, task.Enabled,
+				formatTaskTime(task.LastRun), task.LastResult, formatTaskTime(nextRun))
+		}
+		w.Flush()
+	},
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a scheduled task.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := storage.NewServerStore()
+		server, err := resolveServer(store, scheduleflags.server)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		task := storage.ScheduledTask{
+			ID:       shared.NewTaskID(),
+			Cron:     scheduleflags.cron,
+			Action:   strings.ToLower(scheduleflags.action),
+			Argument: scheduleflags.argument,
+			Enabled:  true,
+		}
+		if err := shared.ValidateTask(task); err != nil {
+			fmt.Println(err)
+			return
+		}
+		task.NextRun = shared.NextTaskRun(task, time.Now())
+
+		err = store.Update(server.ID, func(s *storage.Server) error {
+			s.Tasks = append(s.Tasks, task)
+			return nil
+		})
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		fmt.Printf("✓ Added %s, next run %s\n", task.ID, formatTaskTime(task.NextRun))
+	},
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:   "remove <task-id ...>",
+	Short: "Remove scheduled tasks.",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		editTasks(args, func(tasks []storage.ScheduledTask, i int) []storage.ScheduledTask {
+			return append(tasks[:i], tasks[i+1:]...)
+		})
+	},
+}
+
+var scheduleEnableCmd = &cobra.Command{
+	Use:   "enable <task-id ...>",
+	Short: "Enable scheduled tasks.",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		editTasks(args, func(tasks []storage.ScheduledTask, i int) []storage.ScheduledTask {
+			tasks[i].Enabled = true
+			tasks[i].NextRun = shared.NextTaskRun(tasks[i], time.Now())
+			return tasks
+		})
+	},
+}
+
+var scheduleDisableCmd = &cobra.Command{
+	Use:   "disable <task-id ...>",
+	Short: "Disable scheduled tasks without removing them.",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		editTasks(args, func(tasks []storage.ScheduledTask, i int) []storage.ScheduledTask {
+			tasks[i].Enabled = false
+			return tasks
+		})
+	},
+}
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the scheduler without supervising the server.",
+	Long: `Runs the scheduled tasks of a server that was started elsewhere (e.g. the TUI).
+
+Restart tasks need 'osmium run' and will report an error here, so do update tasks
+while the server is running.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := storage.NewServerStore()
+		server, err := resolveServer(store, scheduleflags.server)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if err := os.Chdir(server.Path); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		fmt.Printf("Running scheduler for %s, press Ctrl-C to exit\n", server.Name)
+		shared.RunScheduler(context.Background(), store, server.ID, shared.SchedulerHooks{})
+	},
+}
+
+// editTasks applies fn to each task ID in ids and saves the server
+func editTasks(ids []string, fn func([]storage.ScheduledTask, int) []storage.ScheduledTask) {
+	store := storage.NewServerStore()
+	server, err := resolveServer(store, scheduleflags.server)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	err = store.Update(server.ID, func(s *storage.Server) error {
+		for _, id := range ids {
+			found := false
+			for i := range s.Tasks {
+				if s.Tasks[i].ID == id {
+					s.Tasks = fn(s.Tasks, i)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("task %s not found", id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println("✓ Tasks updated")
+}
+
+// formatTaskTime renders a task timestamp, or "-" when it's unset
+func formatTaskTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+	scheduleCmd.AddCommand(scheduleListCmd, scheduleAddCmd, scheduleRemoveCmd, scheduleEnableCmd, scheduleDisableCmd, scheduleRunCmd)
+
+	scheduleCmd.PersistentFlags().StringVarP(&scheduleflags.server, "server", "s", "", "ID of the server (defaults to the current directory)")
+
+	scheduleAddCmd.Flags().StringVarP(&scheduleflags.cron, "cron", "c", "", "Cron expression, e.g. \"0 4 * * *\"")
+	scheduleAddCmd.Flags().StringVarP(&scheduleflags.action, "action", "a", "", "Task action: command, restart, backup or update")
+	scheduleAddCmd.Flags().StringVar(&scheduleflags.argument, "arg", "", "Console command for command tasks, \"incremental\" for backups")
+	scheduleAddCmd.MarkFlagRequired("cron")
+	scheduleAddCmd.MarkFlagRequired("action")
+}
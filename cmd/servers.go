@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/limelamp/osmium/internal/tui/storage"
+)
+
+var serverIDUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// resolveServer finds a registered server by ID, or by the current directory when id is empty
+func resolveServer(store *storage.ServerStore, id string) (storage.Server, error) {
+	if id != "" {
+		return store.FindByID(id)
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return storage.Server{}, err
+	}
+	return store.FindByPath(dir)
+}
+
+// newServerID derives a free server ID like "srv-my-pack" from a server name
+func newServerID(store *storage.ServerStore, name string) string {
+	slug := strings.Trim(serverIDUnsafe.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if slug == "" {
+		slug = "server"
+	}
+
+	id := "srv-" + slug
+	for i := 2; ; i++ {
+		if _, err := store.FindByID(id); err != nil {
+			return id
+		}
+		id = fmt.Sprintf("srv-%s-%d", slug, i)
+	}
+}
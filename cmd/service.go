@@ -0,0 +1,145 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/limelamp/osmium/internal/shared"
+	"github.com/limelamp/osmium/internal/tui/storage"
+	"github.com/spf13/cobra"
+)
+
+type serviceFlags struct {
+	server  string
+	user    bool
+	restart string
+	runAs   string
+	noStart bool
+}
+
+var serviceflags serviceFlags
+
+// serviceCmd represents the service command
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Run a server as a systemd service.",
+	Long: `Manages a systemd unit that keeps a server running under 'osmium run'.
+
+The unit starts in the server's folder, stops it gracefully by sending 'stop'
+through the console, and restarts it when it fails.
+System units (the default) need root, --user installs a unit for the current user instead.
+For user units to run without a login session, enable lingering: loginctl enable-linger
+
+The server is picked with --server, or from the current directory.
+Examples:
+  sudo osmium service install --server 1a2b3c
+  osmium service install --server 1a2b3c --user
+  osmium service status --server 1a2b3c
+  osmium service uninstall --server 1a2b3c`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if runtime.GOOS != "linux" {
+			fmt.Println("systemd services are only available on Linux")
+			os.Exit(1)
+		}
+	},
+}
+
+var serviceInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Write, enable and start the systemd unit of a server.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		server, err := resolveServer(storage.NewServerStore(), serviceflags.server)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		switch serviceflags.restart {
+		case "on-failure", "always", "no":
+		default:
+			fmt.Printf("invalid restart policy %q, expected on-failure, always or no\n", serviceflags.restart)
+			return
+		}
+
+		unitPath, err := shared.InstallService(server, shared.ServiceOptions{
+			User:    serviceflags.user,
+			Restart: serviceflags.restart,
+			RunAs:   serviceflags.runAs,
+		}, !serviceflags.noStart)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✓ Installed %s\n", unitPath)
+		if serviceflags.noStart {
+			fmt.Printf("Start it with: systemctl %sstart %s\n", userFlagPrefix(), shared.ServiceName(server))
+		} else {
+			fmt.Printf("✓ %s is running, logs: journalctl %s-u %s -f\n", server.Name, userFlagPrefix(), shared.ServiceName(server))
+		}
+	},
+}
+
+var serviceStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the systemd status of a server's unit.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		server, err := resolveServer(storage.NewServerStore(), serviceflags.server)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		status, err := shared.ServiceStatus(server, serviceflags.user)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Print(status)
+	},
+}
+
+var serviceUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Stop, disable and remove the systemd unit of a server.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		server, err := resolveServer(storage.NewServerStore(), serviceflags.server)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if err := shared.UninstallService(server, serviceflags.user); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Removed the service of %s\n", server.Name)
+	},
+}
+
+// userFlagPrefix returns "--user " for user units, to print systemctl/journalctl hints
+func userFlagPrefix() string {
+	if serviceflags.user {
+		return "--user "
+	}
+	return ""
+}
+
+func init() {
+	rootCmd.AddCommand(serviceCmd)
+	serviceCmd.AddCommand(serviceInstallCmd, serviceStatusCmd, serviceUninstallCmd)
+
+	serviceCmd.PersistentFlags().StringVarP(&serviceflags.server, "server", "s", "", "ID of the server (defaults to the current directory)")
+	serviceCmd.PersistentFlags().BoolVar(&serviceflags.user, "user", false, "Use a systemd user unit instead of a system unit")
+
+	serviceInstallCmd.Flags().StringVar(&serviceflags.restart, "restart", "on-failure", "Restart policy: on-failure, always or no")
+	serviceInstallCmd.Flags().StringVar(&serviceflags.runAs, "run-as", "", "Account a system unit runs as (defaults to the invoking user)")
+	serviceInstallCmd.Flags().BoolVar(&serviceflags.noStart, "no-start", false, "Enable the unit without starting it")
+}
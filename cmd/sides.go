@@ -0,0 +1,94 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/limelamp/osmium/internal/shared"
+	"github.com/spf13/cobra"
+)
+
+type sidesFlags struct {
+	jsonFlag bool
+}
+
+var sidesflags sidesFlags
+
+// sidesCmd represents the sides command
+var sidesCmd = &cobra.Command{
+	Use:   "sides",
+	Short: "Report mods that don't run on servers.",
+	Long: `Looks up every jar in mods/ and plugins/ on Modrinth, tracked or not, and reports the ones
+that are client-side only (shaders, minimaps, Sodium, ...) and may crash the server,
+and the ones Modrinth doesn't know the server side of.
+
+Jars from other sources can't be checked.
+Examples:
+  osmium sides
+  osmium sides --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		report, err := shared.CheckSides()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if sidesflags.jsonFlag {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		printSideReport(report)
+	},
+}
+
+// printSideReport renders the server-unsafe jars as tables
+func printSideReport(report shared.SideReport) {
+	if len(report.ClientOnly) == 0 {
+		fmt.Println("✓ No client-side only mods")
+	} else {
+		fmt.Println("⚠ Client-side only, these may crash the server:")
+		printSideEntries(report.ClientOnly)
+	}
+
+	if len(report.Unknown) > 0 {
+		fmt.Println("\nModrinth doesn't know whether these run on servers:")
+		printSideEntries(report.Unknown)
+	}
+
+	fmt.Printf("\nChecked %d jars", report.Checked)
+	if report.Unchecked > 0 {
+		fmt.Printf(", skipped %d Modrinth doesn't know", report.Unchecked)
+	}
+	fmt.Println()
+}
+
+func printSideEntries(entries []shared.SideReportEntry) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tFILE\tCLIENT\tSERVER\tTRACKED")
+	for _, entry := range entries {
+		tracked := "no"
+		if entry.Tracked {
+			tracked = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s/%s\t%s\t%s\t%s\n", entry.Slug, entry.Folder, entry.FileName, entry.ClientSide, entry.ServerSide, tracked)
+	}
+	w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(sidesCmd)
+
+	sidesCmd.Flags().BoolVar(&sidesflags.jsonFlag, "json", false, "Print the report as JSON")
+}
@@ -15,7 +15,8 @@ var trackCmd = &cobra.Command{
 	Use:   "track",
 	Short: "Track existing jar files into osmium.json.",
 	Long: `Scans the mods and plugins directories, resolves known projects by hash,
-and adds missing entries to osmium.json.
+and adds missing entries to osmium.json. Mods Modrinth marks as client-side only are tracked
+with a warning, 'osmium sides' lists them.
 
 Example:
   osmium track`,
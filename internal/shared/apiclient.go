@@ -0,0 +1,219 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors returned by the project sources, check them with errors.Is
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrIncompatible = errors.New("no compatible versions found")
+	ErrRateLimited  = errors.New("rate limited")
+)
+
+const (
+	apiMaxRetries = 3
+	apiMaxBackoff = 8 * time.Second
+	apiMaxWait    = time.Minute // longest wait for a rate limit window to reset
+)
+
+// apiClient talks to the JSON API of a project source. It identifies itself with a descriptive User-Agent,
+// waits when the rate limit is used up, and retries transient failures with backoff.
+// It's safe for concurrent use, the rate limit state is shared by all workers.
+type apiClient struct {
+	name      string        // shown in errors, e.g. "Modrinth"
+	baseURL   func() string // read on every request so overrides apply
+	userAgent string
+	prepare   func(*http.Request) error // adds credentials, nil when the API needs none
+	http      *http.Client
+
+	mu        sync.Mutex
+	remaining int       // requests left in the current window, -1 when unknown
+	resetAt   time.Time // when the window resets
+}
+
+// osmiumUserAgent follows Modrinth's format: project/version (contact)
+func osmiumUserAgent() string {
+	version := "dev"
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+	return fmt.Sprintf("limelamp/osmium/%s (github.com/limelamp/osmium)", version)
+}
+
+// newAPIClient returns a client for the API at baseURL
+func newAPIClient(name string, baseURL func() string) *apiClient {
+	return &apiClient{
+		name:      name,
+		baseURL:   baseURL,
+		userAgent: osmiumUserAgent(),
+		http:      &http.Client{Timeout: 30 * time.Second},
+		remaining: -1,
+	}
+}
+
+// envURL returns the URL in the environment variable, or fallback when it's unset,
+// so tests and mirrors can point Osmium at a stand-in
+func envURL(variable, fallback string) string {
+	if override := os.Getenv(variable); override != "" {
+		fallback = override
+	}
+	return strings.TrimSuffix(fallback, "/")
+}
+
+// waitForRateLimit blocks until the current window has requests left
+func (c *apiClient) waitForRateLimit() {
+	c.mu.Lock()
+	wait := time.Duration(0)
+	if c.remaining == 0 {
+		wait = min(time.Until(c.resetAt), apiMaxWait)
+	}
+	c.mu.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// updateRateLimit reads the X-Ratelimit headers of a response
+func (c *apiClient) updateRateLimit(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-Ratelimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, _ := strconv.ParseInt(header.Get("X-Ratelimit-Reset"), 10, 64) // seconds until the window resets
+
+	resetAt := time.Now().Add(time.Duration(reset) * time.Second)
+	if reset > 1_000_000_000 {
+		resetAt = time.Unix(reset, 0) // GitHub sends the time of the reset instead
+	}
+
+	c.mu.Lock()
+	c.remaining = remaining
+	c.resetAt = resetAt
+	c.mu.Unlock()
+}
+
+// retryAfter returns how long a 429 response asks to wait
+func retryAfter(header http.Header) time.Duration {
+	for _, name := range []string{"Retry-After", "X-Ratelimit-Reset"} {
+		if seconds, err := strconv.Atoi(header.Get(name)); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, apiMaxWait)
+		}
+	}
+	return time.Second
+}
+
+// apiBackoff returns the delay before retry attempt n, doubling each time with some jitter
+func apiBackoff(attempt int) time.Duration {
+	delay := min(500*time.Millisecond<<attempt, apiMaxBackoff)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// do sends a request and returns the response for a 200. 429s and transient failures (network
+// errors and 5xx) are retried, a 404 is ErrNotFound and an exhausted rate limit is ErrRateLimited.
+func (c *apiClient) do(method, path string, query url.Values, body []byte) (*http.Response, error) {
+	endpoint := c.baseURL() + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		c.waitForRateLimit()
+
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequest(method, endpoint, reader)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", c.userAgent)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if c.prepare != nil {
+			if err := c.prepare(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			if attempt < apiMaxRetries {
+				time.Sleep(apiBackoff(attempt))
+				continue
+			}
+			return nil, err
+		}
+		c.updateRateLimit(resp.Header)
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return resp, nil
+		case resp.StatusCode == http.StatusNotFound:
+			resp.Body.Close()
+			return nil, fmt.Errorf("%w on %s: %s", ErrNotFound, c.name, path)
+		case resp.StatusCode == http.StatusTooManyRequests:
+			resp.Body.Close()
+			if attempt < apiMaxRetries {
+				time.Sleep(retryAfter(resp.Header))
+				continue
+			}
+			return nil, fmt.Errorf("%w by %s: %s", ErrRateLimited, c.name, path)
+		case resp.StatusCode >= 500 && attempt < apiMaxRetries:
+			resp.Body.Close()
+			time.Sleep(apiBackoff(attempt))
+			continue
+		default:
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s %s on %s failed: %s", method, path, c.name, resp.Status)
+		}
+	}
+}
+
+// decodeAPI reads a JSON response into out
+func decodeAPI(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// get fetches path (relative to the base URL, e.g. /project/sodium) and decodes it into out
+func (c *apiClient) get(path string, query url.Values, out any) error {
+	resp, err := c.do("GET", path, query, nil)
+	if err != nil {
+		return err
+	}
+	return decodeAPI(resp, out)
+}
+
+// post sends body as JSON to path and decodes the response into out
+func (c *apiClient) post(path string, body any, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do("POST", path, nil, data)
+	if err != nil {
+		return err
+	}
+	return decodeAPI(resp, out)
+}
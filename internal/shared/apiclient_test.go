@@ -0,0 +1,151 @@
+package shared
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeModrinth points the Modrinth client at a stand-in for the duration of a test
+func fakeModrinth(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	t.Setenv("OSMIUM_MODRINTH_API_URL", server.URL+"/v2/")
+
+	// The rate limit state is shared by every request of the client
+	reset := func() {
+		modrinth.mu.Lock()
+		modrinth.remaining = -1
+		modrinth.resetAt = time.Time{}
+		modrinth.mu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestAPIClientGet(t *testing.T) {
+	var userAgent, path string
+	fakeModrinth(t, func(w http.ResponseWriter, r *http.Request) {
+		userAgent, path = r.UserAgent(), r.URL.Path
+		w.Write([]byte(`{"id":"AANobbMI","slug":"sodium","client_side":"required","server_side":"unsupported"}`))
+	})
+
+	info, err := getProjectInfo("sodium")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Slug != "sodium" || !info.clientOnly() {
+		t.Errorf("got %+v, want client-only sodium", info)
+	}
+	if path != "/v2/project/sodium" {
+		t.Errorf("requested %s, want /v2/project/sodium", path)
+	}
+	if !strings.HasPrefix(userAgent, "limelamp/osmium/") {
+		t.Errorf("User-Agent is %q, want limelamp/osmium/<version>", userAgent)
+	}
+}
+
+func TestAPIClientStatuses(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []int // status of each request, the last one repeats
+		wantErr   error // nil for success
+		wantCalls int32
+	}{
+		{name: "ok", responses: []int{200}, wantCalls: 1},
+		{name: "not found", responses: []int{404}, wantErr: ErrNotFound, wantCalls: 1},
+		{name: "rate limited once", responses: []int{429, 200}, wantCalls: 2},
+		{name: "rate limited", responses: []int{429}, wantErr: ErrRateLimited, wantCalls: apiMaxRetries + 1},
+		{name: "server error once", responses: []int{503, 200}, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			fakeModrinth(t, func(w http.ResponseWriter, r *http.Request) {
+				n := int(calls.Add(1))
+				status := tt.responses[min(n, len(tt.responses))-1]
+				if status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					w.Write([]byte(`{"id":"P","slug":"p"}`))
+				}
+			})
+
+			_, err := getProjectInfo("p")
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("made %d requests, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestAPIClientWaitsForRateLimitReset(t *testing.T) {
+	fakeModrinth(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Remaining", "0")
+		w.Header().Set("X-Ratelimit-Reset", "1")
+		w.Write([]byte(`{"id":"P","slug":"p"}`))
+	})
+
+	if _, err := getProjectInfo("p"); err != nil {
+		t.Fatal(err)
+	}
+
+	started := time.Now()
+	if _, err := getProjectInfo("p"); err != nil {
+		t.Fatal(err)
+	}
+	if waited := time.Since(started); waited < 500*time.Millisecond {
+		t.Errorf("second request went out after %v, want it to wait for the window to reset", waited)
+	}
+}
+
+func TestUpdateRateLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		reset string
+		want  time.Duration // from now
+	}{
+		{name: "seconds until reset", reset: "30", want: 30 * time.Second},
+		{name: "epoch of the reset", reset: strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10), want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newAPIClient("Test", func() string { return "" })
+			client.updateRateLimit(http.Header{"X-Ratelimit-Remaining": {"7"}, "X-Ratelimit-Reset": {tt.reset}})
+
+			if client.remaining != 7 {
+				t.Errorf("remaining is %d, want 7", client.remaining)
+			}
+			if diff := time.Until(client.resetAt) - tt.want; diff < -2*time.Second || diff > 2*time.Second {
+				t.Errorf("resets in %v, want about %v", time.Until(client.resetAt), tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIBackoff(t *testing.T) {
+	for attempt := 0; attempt < 6; attempt++ {
+		delay := min(500*time.Millisecond<<attempt, apiMaxBackoff)
+		for range 20 {
+			if got := apiBackoff(attempt); got < delay/2 || got > delay {
+				t.Fatalf("attempt %d waited %v, want between %v and %v", attempt, got, delay/2, delay)
+			}
+		}
+	}
+}
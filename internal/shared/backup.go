@@ -0,0 +1,520 @@
+package shared
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/limelamp/osmium/internal/util"
+)
+
+const BackupFolder = "backups"
+
+// backupTimeLayout is embedded in archive names so backups sort and parse without extra metadata
+const backupTimeLayout = "20060102-150405"
+
+// Backup describes a single world archive or incremental snapshot inside the backups folder
+type Backup struct {
+	Name        string
+	Path        string
+	CreatedAt   time.Time
+	Size        int64
+	Incremental bool // stored in the deduplicated backup store rather than as a tarball
+}
+
+// RetentionPolicy defines how many hourly, daily and weekly backups survive a prune.
+// Each bucket keeps the newest backup of that hour/day/week.
+type RetentionPolicy struct {
+	Hourly int
+	Daily  int
+	Weekly int
+}
+
+// getWorldFolders returns the world folders that exist for the configured level-name
+func getWorldFolders() []string {
+	levelName := util.GetLevelName()
+
+	var folders []string
+	for _, folder := range []string{levelName, levelName + "_nether", levelName + "_the_end"} {
+		if info, err := os.Stat(folder); err == nil && info.IsDir() {
+			folders = append(folders, folder)
+		}
+	}
+
+	return folders
+}
+
+// pauseSaving flushes the world to disk and stops autosave while the archive is written.
+// The commands go through send, the supervisor's stdin or this server's own console socket, a server started
+// outside osmium has neither and isn't backed up while running. The returned function re-enables saving
+// and must always be called.
+func pauseSaving(flushWait time.Duration, send func(string) error) (func(), error) {
+	if !IsServerRunning() {
+		return func() {}, nil
+	}
+
+	fmt.Println("Server is running, disabling autosave...")
+	if err := send("save-off"); err != nil {
+		return func() {}, fmt.Errorf("can't reach the console of the running server, start it with 'osmium run' or stop it first: %w", err)
+	}
+
+	resume := func() {
+		if err := send("save-on"); err != nil {
+			fmt.Printf("Warning: failed to re-enable saving: %v\n", err)
+			return
+		}
+		fmt.Println("Autosave re-enabled")
+	}
+
+	if err := send("save-all flush"); err != nil {
+		resume()
+		return func() {}, err
+	}
+
+	// The socket can't read console output, so give the server time to finish flushing
+	time.Sleep(flushWait)
+
+	return resume, nil
+}
+
+// addFolderToArchive walks a folder and writes every regular file into the tar stream
+func addFolderToArchive(tw *tar.Writer, folder string) error {
+	return filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// session.lock is held open by the server and isn't needed for a restore
+		if info.Name() == "session.lock" {
+			return nil
+		}
+
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(path)
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tw, file)
+		return err
+	})
+}
+
+// writeArchive writes the given folders into a gzip-compressed tarball at path
+func writeArchive(path string, folders []string) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	for _, folder := range folders {
+		fmt.Printf("Archiving %s...\n", folder)
+		if err := addFolderToArchive(tw, folder); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", folder, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finalize archive: %w", err)
+	}
+
+	return nil
+}
+
+// CreateBackup archives the world folders into the backups folder.
+// While the server is running, saving is paused for the duration of the archive.
+func CreateBackup(flushWait time.Duration) (Backup, error) {
+	return CreateBackupWith(flushWait, SendConsoleCommand)
+}
+
+// CreateBackupWith is CreateBackup with the console commands pausing saving going through send
+func CreateBackupWith(flushWait time.Duration, send func(string) error) (Backup, error) {
+	folders := getWorldFolders()
+	if len(folders) == 0 {
+		return Backup{}, fmt.Errorf("no world folders found for level %q", util.GetLevelName())
+	}
+
+	if err := os.MkdirAll(BackupFolder, 0755); err != nil {
+		return Backup{}, fmt.Errorf("failed to create %s folder: %w", BackupFolder, err)
+	}
+
+	resume, err := pauseSaving(flushWait, send)
+	if err != nil {
+		return Backup{}, fmt.Errorf("failed to pause saving: %w", err)
+	}
+	defer resume()
+
+	createdAt := time.Now()
+	name := fmt.Sprintf("%s-%s.tar.gz", util.GetLevelName(), createdAt.Format(backupTimeLayout))
+	path := filepath.Join(BackupFolder, name)
+
+	if err := writeArchive(path, folders); err != nil {
+		os.Remove(path)
+		return Backup{}, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Backup{}, err
+	}
+
+	return Backup{Name: name, Path: path, CreatedAt: createdAt, Size: info.Size()}, nil
+}
+
+// parseBackupTime extracts the timestamp from a backup archive name
+func parseBackupTime(name string) (time.Time, bool) {
+	base := strings.TrimSuffix(name, ".tar.gz")
+	if len(base) < len(backupTimeLayout) {
+		return time.Time{}, false
+	}
+
+	stamp := base[len(base)-len(backupTimeLayout):]
+	t, err := time.ParseInLocation(backupTimeLayout, stamp, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// ListBackups returns all tarballs and incremental snapshots, newest first
+func ListBackups() ([]Backup, error) {
+	backups, err := listSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(BackupFolder)
+	if os.IsNotExist(err) {
+		return backups, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s folder: %w", BackupFolder, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tar.gz") {
+			continue
+		}
+
+		createdAt, ok := parseBackupTime(entry.Name())
+		if !ok {
+			continue // not created by osmium
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, Backup{
+			Name:      entry.Name(),
+			Path:      filepath.Join(BackupFolder, entry.Name()),
+			CreatedAt: createdAt,
+			Size:      info.Size(),
+		})
+	}
+
+	sortBackups(backups)
+
+	return backups, nil
+}
+
+// findBackup looks a backup up by its archive name
+func findBackup(name string) (Backup, error) {
+	backups, err := ListBackups()
+	if err != nil {
+		return Backup{}, err
+	}
+
+	for _, backup := range backups {
+		if backup.Name == name {
+			return backup, nil
+		}
+	}
+	for _, backup := range backups {
+		if strings.TrimSuffix(backup.Name, ".tar.gz") == name {
+			return backup, nil
+		}
+	}
+
+	return Backup{}, fmt.Errorf("backup %s not found", name)
+}
+
+// newRestoreStaging creates the folder a backup is restored into before it replaces the world,
+// next to the server so the swap is a rename
+func newRestoreStaging(id string) (string, error) {
+	staging := ".restore-" + id
+	if err := os.RemoveAll(staging); err != nil {
+		return "", fmt.Errorf("failed to clear %s: %w", staging, err)
+	}
+	if err := os.Mkdir(staging, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", staging, err)
+	}
+	return staging, nil
+}
+
+// swapInRestored replaces every world folder in the staging folder with the restored one.
+// The old folders are moved aside first and put back if a rename fails, then deleted.
+func swapInRestored(staging string) error {
+	entries, err := os.ReadDir(staging)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", staging, err)
+	}
+
+	old := staging + "-old"
+	if err := os.RemoveAll(old); err != nil {
+		return fmt.Errorf("failed to clear %s: %w", old, err)
+	}
+	if err := os.Mkdir(old, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", old, err)
+	}
+
+	var movedAside, placed []string
+	undo := func() {
+		for _, root := range placed {
+			os.Rename(root, filepath.Join(staging, root))
+		}
+		for _, root := range movedAside {
+			os.Rename(filepath.Join(old, root), root)
+		}
+	}
+
+	for _, entry := range entries {
+		root := entry.Name()
+		if _, err := os.Lstat(root); err == nil {
+			if err := os.Rename(root, filepath.Join(old, root)); err != nil {
+				undo()
+				return fmt.Errorf("failed to move %s aside, the world is unchanged: %w", root, err)
+			}
+			movedAside = append(movedAside, root)
+		}
+		if err := os.Rename(filepath.Join(staging, root), root); err != nil {
+			undo()
+			return fmt.Errorf("failed to move the restored %s into place, the world is unchanged: %w", root, err)
+		}
+		placed = append(placed, root)
+	}
+
+	os.RemoveAll(staging)
+	if err := os.RemoveAll(old); err != nil {
+		fmt.Printf("Warning: failed to remove the old world in %s: %v\n", old, err)
+	}
+	return nil
+}
+
+// extractArchive unpacks a tarball into the staging folder
+func extractArchive(path string, staging string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if filepath.IsAbs(name) || strings.HasPrefix(name, "..") || name == "." {
+			return fmt.Errorf("archive contains unsafe path: %s", header.Name)
+		}
+		target := filepath.Join(staging, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0777)
+			if err != nil {
+				return err
+			}
+
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			if err := out.Close(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// restoreArchive extracts a tarball next to the server and swaps its world folders in once it's complete,
+// so a broken archive or a full disk leaves the world as it was
+func restoreArchive(backup Backup) error {
+	staging, err := newRestoreStaging(strings.TrimSuffix(backup.Name, ".tar.gz"))
+	if err != nil {
+		return err
+	}
+
+	if err := extractArchive(backup.Path, staging); err != nil {
+		os.RemoveAll(staging)
+		return fmt.Errorf("%w\nthe world is unchanged", err)
+	}
+
+	return swapInRestored(staging)
+}
+
+// RestoreBackup replaces the world folders with the contents of a backup.
+// filePath restores a single file instead, which only incremental snapshots support.
+// The server must be stopped, otherwise it would overwrite the restored files.
+func RestoreBackup(name string, filePath string) error {
+	if IsServerRunning() {
+		return fmt.Errorf("server is running, stop it with 'osmium stop' before restoring")
+	}
+
+	backup, err := findBackup(name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case backup.Incremental:
+		if filePath == "" {
+			fmt.Printf("Restoring %s...\n", backup.Name)
+		}
+		if err := restoreSnapshot(backup, filePath); err != nil {
+			return err
+		}
+	case filePath != "":
+		return fmt.Errorf("single file restore is only supported for incremental backups")
+	default:
+		fmt.Printf("Restoring %s...\n", backup.Name)
+		if err := restoreArchive(backup); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("✓ Backup restored")
+	return nil
+}
+
+// selectRetained marks which backups survive the retention policy.
+// backups must be sorted newest first.
+func selectRetained(backups []Backup, policy RetentionPolicy) map[string]bool {
+	keep := make(map[string]bool)
+
+	rules := []struct {
+		count  int
+		bucket func(time.Time) string
+	}{
+		{policy.Hourly, func(t time.Time) string { return t.Format("2006010215") }},
+		{policy.Daily, func(t time.Time) string { return t.Format("20060102") }},
+		{policy.Weekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-%02d", year, week)
+		}},
+	}
+
+	for _, rule := range rules {
+		seen := make(map[string]bool)
+		for _, backup := range backups {
+			if len(seen) >= rule.count {
+				break
+			}
+
+			bucket := rule.bucket(backup.CreatedAt)
+			if seen[bucket] {
+				continue
+			}
+
+			seen[bucket] = true
+			keep[backup.Name] = true
+		}
+	}
+
+	return keep
+}
+
+// PruneBackups deletes every backup not retained by the policy and returns the removed ones.
+// Chunks only referenced by removed snapshots are deleted from the backup store.
+func PruneBackups(policy RetentionPolicy) ([]Backup, error) {
+	if policy.Hourly <= 0 && policy.Daily <= 0 && policy.Weekly <= 0 {
+		return nil, fmt.Errorf("retention policy would remove every backup")
+	}
+
+	// Nothing may store chunks while snapshots are removed and their objects collected
+	unlock, err := lockStore()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	backups, err := ListBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	keep := selectRetained(backups, policy)
+
+	var removed []Backup
+	for _, backup := range backups {
+		if keep[backup.Name] {
+			continue
+		}
+
+		if err := os.Remove(backup.Path); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove %s: %w", backup.Name, err)
+		}
+		removed = append(removed, backup)
+	}
+
+	if _, err := collectGarbage(); err != nil {
+		return removed, fmt.Errorf("failed to clean up backup store: %w", err)
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,584 @@
+package shared
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/limelamp/osmium/internal/util"
+)
+
+/*
+	Incremental backups live in a content-addressed store:
+		backups/store/objects/<first two hex chars>/<sha256>  gzip-compressed chunk
+		backups/store/snapshots/<id>.json                     manifest listing every file and its chunks
+	Files are split into fixed-size chunks, so a snapshot only writes the chunks that changed since
+	any earlier snapshot. Region files (.mca) are chunked along their 4 KiB sector boundaries.
+
+	A snapshot's chunks aren't referenced until its manifest is written, so backups and garbage
+	collection take backups/store/.lock (holding the PID) and never run at the same time.
+*/
+
+var (
+	backupStoreFolder = filepath.Join(BackupFolder, "store")
+	objectsFolder     = filepath.Join(backupStoreFolder, "objects")
+	snapshotsFolder   = filepath.Join(backupStoreFolder, "snapshots")
+	storeLockPath     = filepath.Join(backupStoreFolder, ".lock")
+)
+
+const (
+	regionChunkSize  = 64 * 1024   // 16 region sectors
+	defaultChunkSize = 1024 * 1024 // everything that isn't a region file
+
+	storeLockTimeout = 10 * time.Minute
+)
+
+type snapshotFile struct {
+	Path   string      `json:"path"`
+	Mode   os.FileMode `json:"mode"`
+	Size   int64       `json:"size"`
+	Chunks []string    `json:"chunks"`
+}
+
+type snapshotManifest struct {
+	ID        string         `json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	Files     []snapshotFile `json:"files"`
+}
+
+// SnapshotStats reports how much new data an incremental backup had to store
+type SnapshotStats struct {
+	Files       int
+	Chunks      int
+	NewChunks   int
+	StoredBytes int64
+}
+
+// chunkSizeFor picks the chunk size for a file based on its type
+func chunkSizeFor(path string) int {
+	if strings.HasSuffix(path, ".mca") {
+		return regionChunkSize
+	}
+	return defaultChunkSize
+}
+
+func objectPath(hash string) string {
+	return filepath.Join(objectsFolder, hash[:2], hash)
+}
+
+// storeChunk writes a chunk into the object store unless it already exists.
+// Returns the chunk hash and the compressed bytes written (0 when the chunk was already stored).
+func storeChunk(data []byte) (string, int64, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	path := objectPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, 0, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", 0, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return "", 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return "", 0, err
+	}
+
+	// Write to a temp file first so an interrupted backup never leaves a corrupt object behind
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", 0, err
+	}
+
+	return hash, int64(buf.Len()), nil
+}
+
+// lockStore takes the exclusive lock of the backup store, waiting for another backup or prune to finish.
+// A lock whose process is gone is left over from a crash and taken over.
+func lockStore() (func(), error) {
+	if err := os.MkdirAll(backupStoreFolder, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup store: %w", err)
+	}
+
+	deadline := time.Now().Add(storeLockTimeout)
+	waiting := false
+	for {
+		file, err := os.OpenFile(storeLockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, err = file.WriteString(strconv.Itoa(os.Getpid()))
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(storeLockPath)
+				return nil, fmt.Errorf("failed to lock backup store: %w", err)
+			}
+			return func() { os.Remove(storeLockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock backup store: %w", err)
+		}
+
+		// The holder writes its PID right after creating the lock, an empty lock is only stale once it's old
+		data, err := os.ReadFile(storeLockPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err == nil && !IsPIDRunning(pid) {
+			os.Remove(storeLockPath)
+			continue
+		}
+		if info, statErr := os.Stat(storeLockPath); err != nil && statErr == nil && time.Since(info.ModTime()) > time.Minute {
+			os.Remove(storeLockPath)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("backup store is still locked by PID %d, remove %s if no backup is running", pid, storeLockPath)
+		}
+		if !waiting {
+			fmt.Printf("Waiting for another backup or prune (PID %d) to finish...\n", pid)
+			waiting = true
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// readChunk loads a chunk from the object store and checks it against its hash
+func readChunk(hash string) ([]byte, error) {
+	file, err := os.Open(objectPath(hash))
+	if err != nil {
+		return nil, fmt.Errorf("missing chunk %s: %w", hash, err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt chunk %s: %w", hash, err)
+	}
+	defer gz.Close()
+
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt chunk %s: %w", hash, err)
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != hash {
+		return nil, fmt.Errorf("chunk %s failed integrity check", hash)
+	}
+
+	return data, nil
+}
+
+// storeFile splits a file into chunks and stores the ones not yet present
+func storeFile(path string, info os.FileInfo, stats *SnapshotStats) (snapshotFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return snapshotFile{}, err
+	}
+	defer file.Close()
+
+	entry := snapshotFile{
+		Path: filepath.ToSlash(path),
+		Mode: info.Mode().Perm(),
+		Size: info.Size(),
+	}
+
+	buf := make([]byte, chunkSizeFor(path))
+	for {
+		n, err := io.ReadFull(file, buf)
+		if n > 0 {
+			hash, written, storeErr := storeChunk(buf[:n])
+			if storeErr != nil {
+				return snapshotFile{}, fmt.Errorf("failed to store chunk of %s: %w", path, storeErr)
+			}
+
+			entry.Chunks = append(entry.Chunks, hash)
+			stats.Chunks++
+			if written > 0 {
+				stats.NewChunks++
+				stats.StoredBytes += written
+			}
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return snapshotFile{}, err
+		}
+	}
+
+	return entry, nil
+}
+
+// CreateIncrementalBackup stores the world folders in the deduplicated backup store
+func CreateIncrementalBackup(flushWait time.Duration) (Backup, SnapshotStats, error) {
+	return CreateIncrementalBackupWith(flushWait, SendConsoleCommand)
+}
+
+// CreateIncrementalBackupWith is CreateIncrementalBackup with the console commands pausing saving going through send
+func CreateIncrementalBackupWith(flushWait time.Duration, send func(string) error) (Backup, SnapshotStats, error) {
+	var stats SnapshotStats
+
+	folders := getWorldFolders()
+	if len(folders) == 0 {
+		return Backup{}, stats, fmt.Errorf("no world folders found for level %q", util.GetLevelName())
+	}
+
+	if err := os.MkdirAll(snapshotsFolder, 0755); err != nil {
+		return Backup{}, stats, fmt.Errorf("failed to create backup store: %w", err)
+	}
+
+	// Held until the manifest references every chunk, before saving is paused so waiting doesn't keep it off
+	unlock, err := lockStore()
+	if err != nil {
+		return Backup{}, stats, err
+	}
+	defer unlock()
+
+	resume, err := pauseSaving(flushWait, send)
+	if err != nil {
+		return Backup{}, stats, fmt.Errorf("failed to pause saving: %w", err)
+	}
+	defer resume()
+
+	createdAt := time.Now()
+	manifest := snapshotManifest{
+		ID:        fmt.Sprintf("%s-%s", util.GetLevelName(), createdAt.Format(backupTimeLayout)),
+		CreatedAt: createdAt,
+	}
+
+	for _, folder := range folders {
+		fmt.Printf("Storing %s...\n", folder)
+
+		err := filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() || info.Name() == "session.lock" {
+				return nil
+			}
+
+			entry, err := storeFile(path, info, &stats)
+			if err != nil {
+				return err
+			}
+
+			manifest.Files = append(manifest.Files, entry)
+			stats.Files++
+			return nil
+		})
+		if err != nil {
+			return Backup{}, stats, fmt.Errorf("failed to store %s: %w", folder, err)
+		}
+	}
+
+	path, err := writeManifest(manifest)
+	if err != nil {
+		return Backup{}, stats, err
+	}
+
+	return manifestToBackup(manifest, path), stats, nil
+}
+
+func writeManifest(manifest snapshotManifest) (string, error) {
+	data, err := json.MarshalIndent(manifest, "", " ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal snapshot manifest: %w", err)
+	}
+
+	path := filepath.Join(snapshotsFolder, manifest.ID+".json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write snapshot manifest: %w", err)
+	}
+
+	return path, nil
+}
+
+func readManifest(path string) (snapshotManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snapshotManifest{}, err
+	}
+
+	var manifest snapshotManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return snapshotManifest{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return manifest, nil
+}
+
+func manifestToBackup(manifest snapshotManifest, path string) Backup {
+	var size int64
+	for _, file := range manifest.Files {
+		size += file.Size
+	}
+
+	return Backup{
+		Name:        manifest.ID,
+		Path:        path,
+		CreatedAt:   manifest.CreatedAt,
+		Size:        size,
+		Incremental: true,
+	}
+}
+
+// listSnapshots returns every incremental snapshot in the store
+func listSnapshots() ([]Backup, error) {
+	entries, err := os.ReadDir(snapshotsFolder)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup store: %w", err)
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		path := filepath.Join(snapshotsFolder, entry.Name())
+		manifest, err := readManifest(path)
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+			continue
+		}
+
+		backups = append(backups, manifestToBackup(manifest, path))
+	}
+
+	return backups, nil
+}
+
+// writeSnapshotFile reassembles a single file from its chunks
+func writeSnapshotFile(file snapshotFile, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	tmp := target + ".osmium-restore"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, file.Mode|0200)
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range file.Chunks {
+		data, err := readChunk(hash)
+		if err != nil {
+			out.Close()
+			os.Remove(tmp)
+			return err
+		}
+		if _, err := out.Write(data); err != nil {
+			out.Close()
+			os.Remove(tmp)
+			return err
+		}
+	}
+
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, target)
+}
+
+// restoreSnapshot replaces the world folders with a snapshot, or restores just one file when filePath is set
+func restoreSnapshot(backup Backup, filePath string) error {
+	manifest, err := readManifest(backup.Path)
+	if err != nil {
+		return err
+	}
+
+	if filePath != "" {
+		wanted := filepath.ToSlash(filepath.Clean(filePath))
+		for _, file := range manifest.Files {
+			if file.Path == wanted {
+				fmt.Printf("Restoring %s from %s...\n", file.Path, manifest.ID)
+				return writeSnapshotFile(file, filepath.FromSlash(file.Path))
+			}
+		}
+		return fmt.Errorf("%s is not part of snapshot %s", filePath, manifest.ID)
+	}
+
+	// Check every chunk first so a broken snapshot fails before anything is written
+	if problems := verifyManifest(manifest, make(map[string]chunkResult)); len(problems) > 0 {
+		return fmt.Errorf("snapshot %s failed verification: %w", manifest.ID, problems[0])
+	}
+
+	// Reassemble the world next to the server and swap it in once every file is written
+	staging, err := newRestoreStaging(manifest.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range manifest.Files {
+		target := filepath.Clean(filepath.FromSlash(file.Path))
+		if filepath.IsAbs(target) || strings.HasPrefix(target, "..") || target == "." {
+			os.RemoveAll(staging)
+			return fmt.Errorf("snapshot contains unsafe path: %s", file.Path)
+		}
+
+		if err := writeSnapshotFile(file, filepath.Join(staging, target)); err != nil {
+			os.RemoveAll(staging)
+			return fmt.Errorf("failed to restore %s: %w\nthe world is unchanged", file.Path, err)
+		}
+	}
+
+	return swapInRestored(staging)
+}
+
+// chunkResult caches the outcome of reading a chunk during verification
+type chunkResult struct {
+	size int64
+	err  error
+}
+
+// verifyManifest checks every chunk referenced by a manifest.
+// checked caches results so chunks shared between snapshots are only read once.
+func verifyManifest(manifest snapshotManifest, checked map[string]chunkResult) []error {
+	var problems []error
+
+	for _, file := range manifest.Files {
+		var size int64
+		broken := false
+
+		for _, hash := range file.Chunks {
+			result, ok := checked[hash]
+			if !ok {
+				data, err := readChunk(hash)
+				result = chunkResult{size: int64(len(data)), err: err}
+				checked[hash] = result
+			}
+
+			if result.err != nil {
+				problems = append(problems, fmt.Errorf("%s: %w", file.Path, result.err))
+				broken = true
+			}
+			size += result.size
+		}
+
+		if !broken && size != file.Size {
+			problems = append(problems, fmt.Errorf("%s: expected %d bytes, chunks hold %d", file.Path, file.Size, size))
+		}
+	}
+
+	return problems
+}
+
+// VerifyBackups checks the integrity of one snapshot (or all when name is empty)
+// and returns every problem found.
+func VerifyBackups(name string) ([]error, error) {
+	snapshots, err := listSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	checked := make(map[string]chunkResult)
+	var problems []error
+	found := false
+
+	for _, snapshot := range snapshots {
+		if name != "" && snapshot.Name != name {
+			continue
+		}
+		found = true
+
+		manifest, err := readManifest(snapshot.Path)
+		if err != nil {
+			problems = append(problems, err)
+			continue
+		}
+
+		fmt.Printf("Verifying %s (%d files)...\n", manifest.ID, len(manifest.Files))
+		for _, problem := range verifyManifest(manifest, checked) {
+			problems = append(problems, fmt.Errorf("%s: %w", manifest.ID, problem))
+		}
+	}
+
+	if name != "" && !found {
+		return nil, fmt.Errorf("snapshot %s not found", name)
+	}
+
+	return problems, nil
+}
+
+// collectGarbage deletes objects no longer referenced by any snapshot, the caller holds the store lock
+func collectGarbage() (int, error) {
+	entries, err := os.ReadDir(snapshotsFolder)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to read backup store: %w", err)
+	}
+
+	referenced := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		manifest, err := readManifest(filepath.Join(snapshotsFolder, entry.Name()))
+		if err != nil {
+			// Can't tell what this snapshot needs, so keep everything
+			return 0, fmt.Errorf("kept every object, %w", err)
+		}
+		for _, file := range manifest.Files {
+			for _, hash := range file.Chunks {
+				referenced[hash] = true
+			}
+		}
+	}
+
+	removed := 0
+	err = filepath.Walk(objectsFolder, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || referenced[info.Name()] {
+			return nil
+		}
+
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+
+	return removed, err
+}
+
+// sortBackups orders backups newest first
+func sortBackups(backups []Backup) {
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+}
@@ -0,0 +1,73 @@
+package shared
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+)
+
+// Modrinth's bulk endpoints answer for many projects in one request, so a 150-mod server
+// costs a handful of calls instead of hundreds.
+
+// projectIDsPerRequest keeps GET /v2/projects URLs at a reasonable length
+const projectIDsPerRequest = 100
+
+// getVersionsByHash looks up the versions of many files by their SHA1 in one request.
+// Files Modrinth doesn't know are missing from the result.
+func getVersionsByHash(hashes []string) (map[string]modrinthVersion, error) {
+	versions := make(map[string]modrinthVersion)
+	if len(hashes) == 0 {
+		return versions, nil
+	}
+
+	body := map[string]any{"hashes": hashes, "algorithm": "sha1"}
+	if err := modrinth.post("/version_files", body, &versions); err != nil {
+		return nil, fmt.Errorf("failed to look up files: %w", err)
+	}
+	return versions, nil
+}
+
+// getLatestVersionsByHash returns the newest version compatible with the server for each file, by SHA1
+func getLatestVersionsByHash(hashes []string, conf *config.OsmiumConfig) (map[string]modrinthVersion, error) {
+	versions := make(map[string]modrinthVersion)
+	if len(hashes) == 0 {
+		return versions, nil
+	}
+
+	body := map[string]any{
+		"hashes":        hashes,
+		"algorithm":     "sha1",
+		"loaders":       compatibleLoaders(conf),
+		"game_versions": []string{conf.Version},
+	}
+	if err := modrinth.post("/version_files/update", body, &versions); err != nil {
+		return nil, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	return versions, nil
+}
+
+// getProjectsByID fetches the info of many projects, keyed by project ID
+func getProjectsByID(ids []string) (map[string]projectInfo, error) {
+	projects := make(map[string]projectInfo)
+
+	for start := 0; start < len(ids); start += projectIDsPerRequest {
+		batch := ids[start:min(start+projectIDsPerRequest, len(ids))]
+		encoded, err := json.Marshal(batch)
+		if err != nil {
+			return nil, err
+		}
+
+		var infos []projectInfo
+		if err := modrinth.get("/projects", url.Values{"ids": {string(encoded)}}, &infos); err != nil {
+			return nil, fmt.Errorf("failed to fetch projects: %w", err)
+		}
+
+		for _, info := range infos {
+			projects[info.ID] = info
+		}
+	}
+
+	return projects, nil
+}
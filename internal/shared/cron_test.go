@@ -0,0 +1,95 @@
+package shared
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	at := func(value string) time.Time {
+		t.Helper()
+		parsed, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from string
+		want string // empty when nothing matches
+	}{
+		{name: "every minute", expr: "* * * * *", from: "2026-10-19 10:07", want: "2026-10-19 10:08"},
+		{name: "strictly after", expr: "0 4 * * *", from: "2026-10-19 04:00", want: "2026-10-20 04:00"},
+		{name: "step", expr: "*/15 * * * *", from: "2026-10-19 10:07", want: "2026-10-19 10:15"},
+		{name: "step wraps into the next hour", expr: "*/15 * * * *", from: "2026-10-19 10:50", want: "2026-10-19 11:00"},
+		{name: "range", expr: "0 9-17 * * *", from: "2026-10-19 17:30", want: "2026-10-20 09:00"},
+		{name: "range with a step", expr: "30 9-17/4 * * *", from: "2026-10-19 10:00", want: "2026-10-19 13:30"},
+		{name: "start with a step", expr: "5/20 * * * *", from: "2026-10-19 10:06", want: "2026-10-19 10:25"},
+		{name: "list", expr: "0 6,18 * * *", from: "2026-10-19 07:00", want: "2026-10-19 18:00"},
+		{name: "list of ranges", expr: "0 1-2,22-23 * * *", from: "2026-10-19 03:00", want: "2026-10-19 22:00"},
+		{name: "day of week", expr: "0 0 * * 0", from: "2026-10-19 12:00", want: "2026-10-25 00:00"},
+		{name: "7 is sunday", expr: "0 0 * * 7", from: "2026-10-19 12:00", want: "2026-10-25 00:00"},
+		{name: "weekdays", expr: "0 8 * * 1-5", from: "2026-10-23 09:00", want: "2026-10-26 08:00"},
+		{name: "day of week or day of month, weekday first", expr: "0 0 13 * 5", from: "2026-10-19 12:00", want: "2026-10-23 00:00"},
+		{name: "day of week or day of month, day first", expr: "0 0 26 * 5", from: "2026-10-24 12:00", want: "2026-10-26 00:00"},
+		{name: "day of month", expr: "0 0 15 * *", from: "2026-10-19 12:00", want: "2026-11-15 00:00"},
+		{name: "month rollover", expr: "0 0 1 * *", from: "2026-01-31 12:00", want: "2026-02-01 00:00"},
+		{name: "skips short months", expr: "0 0 31 * *", from: "2026-04-01 00:00", want: "2026-05-31 00:00"},
+		{name: "year rollover", expr: "0 0 1 1 *", from: "2026-06-01 00:00", want: "2027-01-01 00:00"},
+		{name: "leap day", expr: "0 0 29 2 *", from: "2026-03-01 00:00", want: "2028-02-29 00:00"},
+		{name: "month", expr: "0 0 1 3,9 *", from: "2026-03-01 00:00", want: "2026-09-01 00:00"},
+		{name: "macro", expr: "@weekly", from: "2026-10-19 12:00", want: "2026-10-25 00:00"},
+		{name: "never", expr: "0 0 31 2 *", from: "2026-01-01 00:00", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+
+			got := schedule.Next(at(tt.from))
+			if tt.want == "" {
+				if !got.IsZero() {
+					t.Errorf("%q after %s = %s, want no match", tt.expr, tt.from, got.Format("2006-01-02 15:04"))
+				}
+				return
+			}
+			if want := at(tt.want); !got.Equal(want) {
+				t.Errorf("%q after %s = %s, want %s", tt.expr, tt.from, got.Format("2006-01-02 15:04"), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronRejects(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"1-x * * * *",
+		"a * * * *",
+		"1,,2 * * * *",
+		"@yearly",
+	}
+
+	for _, expr := range tests {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", expr)
+		}
+	}
+}
@@ -0,0 +1,430 @@
+package shared
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+	"github.com/limelamp/osmium/internal/util"
+)
+
+// CurseForgeAPIURL is the base URL of the CurseForge API. OSMIUM_CURSEFORGE_API_URL overrides it.
+var CurseForgeAPIURL = "https://api.curseforge.com/v1"
+
+// ErrNoCurseForgeKey is returned when CurseForge is used without an API key
+var ErrNoCurseForgeKey = errors.New("CurseForge needs an API key, set one with 'osmium curseforge key <api-key>' or OSMIUM_CURSEFORGE_API_KEY")
+
+// curseforge is the client used by every CurseForge call
+var curseforge = newAPIClient("CurseForge", func() string {
+	return envURL("OSMIUM_CURSEFORGE_API_URL", CurseForgeAPIURL)
+})
+
+func init() {
+	curseforge.prepare = setCurseForgeKey
+}
+
+const (
+	curseforgeMinecraft = 432 // game ID of Minecraft
+	curseforgeMods      = 6   // class ID of mods
+
+	curseforgeHashSHA1 = 1
+
+	curseforgeEmbedded     = 1 // dependency relation types
+	curseforgeOptional     = 2
+	curseforgeRequired     = 3
+	curseforgeIncompatible = 5
+	curseforgeInclude      = 6
+
+	curseforgeFilesPerRequest = 50
+)
+
+// curseforgeLoaders maps server loaders to CurseForge mod loader types
+var curseforgeLoaders = map[string]int{
+	"forge":    1,
+	"fabric":   4,
+	"quilt":    5,
+	"neoforge": 6,
+}
+
+// curseforgeAPIKey returns the API key from OSMIUM_CURSEFORGE_API_KEY or the app config
+func curseforgeAPIKey() string {
+	if key := os.Getenv("OSMIUM_CURSEFORGE_API_KEY"); key != "" {
+		return key
+	}
+	cfg, _ := config.LoadConfig()
+	return cfg.CurseForgeAPIKey
+}
+
+// setCurseForgeKey adds the API key to a request
+func setCurseForgeKey(req *http.Request) error {
+	key := curseforgeAPIKey()
+	if key == "" {
+		return ErrNoCurseForgeKey
+	}
+	req.Header.Set("x-api-key", key)
+	return nil
+}
+
+type curseforgeMod struct {
+	ID            int     `json:"id"`
+	Name          string  `json:"name"`
+	Slug          string  `json:"slug"`
+	Summary       string  `json:"summary"`
+	DownloadCount float64 `json:"downloadCount"`
+	Categories    []struct {
+		Name string `json:"name"`
+	} `json:"categories"`
+	Links struct {
+		WebsiteURL string `json:"websiteUrl"`
+	} `json:"links"`
+}
+
+type curseforgeFile struct {
+	ID          int       `json:"id"`
+	ModID       int       `json:"modId"`
+	DisplayName string    `json:"displayName"`
+	FileName    string    `json:"fileName"`
+	FileDate    time.Time `json:"fileDate"`
+	DownloadURL string    `json:"downloadUrl"` // null when the author doesn't allow third-party downloads
+	Hashes      []struct {
+		Value string `json:"value"`
+		Algo  int    `json:"algo"`
+	} `json:"hashes"`
+	Dependencies []struct {
+		ModID        int `json:"modId"`
+		RelationType int `json:"relationType"`
+	} `json:"dependencies"`
+	FileFingerprint uint32 `json:"fileFingerprint"`
+}
+
+// curseforgeSource installs mods from CurseForge
+type curseforgeSource struct{}
+
+func (curseforgeSource) name() string {
+	return SourceCurseForge
+}
+
+// curseforgeLoader returns the mod loader type of the configured loader
+func curseforgeLoader(conf *config.OsmiumConfig) (int, error) {
+	loader, ok := curseforgeLoaders[strings.ToLower(conf.Loader)]
+	if !ok {
+		return 0, fmt.Errorf("%w: CurseForge has no mods for %s servers", ErrIncompatible, conf.Loader)
+	}
+	return loader, nil
+}
+
+// getCurseForgeMod fetches a mod by its numeric ID or its slug
+func getCurseForgeMod(projectID string) (curseforgeMod, error) {
+	if id, err := strconv.Atoi(projectID); err == nil {
+		var response struct {
+			Data curseforgeMod `json:"data"`
+		}
+		if err := curseforge.get("/mods/"+strconv.Itoa(id), nil, &response); err != nil {
+			return curseforgeMod{}, fmt.Errorf("failed to fetch project info: %w", err)
+		}
+		return response.Data, nil
+	}
+
+	q := url.Values{}
+	q.Set("gameId", strconv.Itoa(curseforgeMinecraft))
+	q.Set("classId", strconv.Itoa(curseforgeMods))
+	q.Set("slug", projectID)
+
+	var response struct {
+		Data []curseforgeMod `json:"data"`
+	}
+	if err := curseforge.get("/mods/search", q, &response); err != nil {
+		return curseforgeMod{}, fmt.Errorf("failed to fetch project info: %w", err)
+	}
+	for _, mod := range response.Data {
+		if strings.EqualFold(mod.Slug, projectID) {
+			return mod, nil
+		}
+	}
+	return curseforgeMod{}, fmt.Errorf("failed to fetch project info: %w on CurseForge: %s", ErrNotFound, projectID)
+}
+
+// getCurseForgeMods fetches many mods at once, keyed by ID
+func getCurseForgeMods(ids []int) (map[int]curseforgeMod, error) {
+	mods := make(map[int]curseforgeMod)
+	if len(ids) == 0 {
+		return mods, nil
+	}
+
+	var response struct {
+		Data []curseforgeMod `json:"data"`
+	}
+	if err := curseforge.post("/mods", map[string]any{"modIds": ids}, &response); err != nil {
+		return nil, fmt.Errorf("failed to fetch projects: %w", err)
+	}
+	for _, mod := range response.Data {
+		mods[mod.ID] = mod
+	}
+	return mods, nil
+}
+
+func (curseforgeSource) search(query string, conf *config.OsmiumConfig, limit int) ([]SearchResult, error) {
+	loader, err := curseforgeLoader(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Set("gameId", strconv.Itoa(curseforgeMinecraft))
+	q.Set("classId", strconv.Itoa(curseforgeMods))
+	q.Set("searchFilter", query)
+	q.Set("gameVersion", conf.Version)
+	q.Set("modLoaderType", strconv.Itoa(loader))
+	q.Set("sortField", "2") // popularity
+	q.Set("sortOrder", "desc")
+	q.Set("pageSize", strconv.Itoa(limit))
+
+	var response struct {
+		Data []curseforgeMod `json:"data"`
+	}
+	if err := curseforge.get("/mods/search", q, &response); err != nil {
+		return nil, fmt.Errorf("failed to search CurseForge: %w", err)
+	}
+
+	results := make([]SearchResult, len(response.Data))
+	for i, mod := range response.Data {
+		result := SearchResult{
+			ProjectID:   strconv.Itoa(mod.ID),
+			Slug:        mod.Slug,
+			Title:       mod.Name,
+			Description: mod.Summary,
+			Downloads:   int(mod.DownloadCount),
+		}
+		for _, category := range mod.Categories {
+			result.Categories = append(result.Categories, category.Name)
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+func (curseforgeSource) resolve(projectID string) (string, error) {
+	mod, err := getCurseForgeMod(projectID)
+	if err != nil {
+		return "", err
+	}
+	return mod.Slug, nil
+}
+
+func (curseforgeSource) versions(slug string, conf *config.OsmiumConfig) ([]projectVersion, error) {
+	loader, err := curseforgeLoader(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	mod, err := getCurseForgeMod(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Set("gameVersion", conf.Version)
+	q.Set("modLoaderType", strconv.Itoa(loader))
+	q.Set("pageSize", strconv.Itoa(curseforgeFilesPerRequest))
+
+	var response struct {
+		Data []curseforgeFile `json:"data"`
+	}
+	if err := curseforge.get("/mods/"+strconv.Itoa(mod.ID)+"/files", q, &response); err != nil {
+		return nil, fmt.Errorf("failed to fetch versions: %w", err)
+	}
+	if len(response.Data) == 0 {
+		return nil, fmt.Errorf("%w for Minecraft %s", ErrIncompatible, conf.Version)
+	}
+
+	sort.SliceStable(response.Data, func(i, j int) bool {
+		return response.Data[i].FileDate.After(response.Data[j].FileDate)
+	})
+
+	versions := make([]projectVersion, len(response.Data))
+	for i, file := range response.Data {
+		versions[i] = file.projectVersion(mod)
+	}
+	return versions, nil
+}
+
+// manualURL is the page a file is downloaded from by hand
+func (f curseforgeFile) manualURL(mod curseforgeMod) string {
+	page := mod.Links.WebsiteURL
+	if page == "" {
+		page = "https://www.curseforge.com/minecraft/mc-mods/" + mod.Slug
+	}
+	return fmt.Sprintf("%s/files/%d", strings.TrimSuffix(page, "/"), f.ID)
+}
+
+// projectVersion converts a CurseForge file. Files the author doesn't allow third-party downloads of
+// have no download URL, they get the page to download them from by hand instead.
+func (f curseforgeFile) projectVersion(mod curseforgeMod) projectVersion {
+	version := projectVersion{
+		Source:        SourceCurseForge,
+		ProjectID:     strconv.Itoa(f.ModID),
+		ID:            strconv.Itoa(f.ID),
+		VersionNumber: f.DisplayName,
+		FileName:      f.FileName,
+		URL:           f.DownloadURL,
+	}
+	if f.DownloadURL == "" {
+		version.ExternalURL = f.manualURL(mod)
+	}
+
+	for _, hash := range f.Hashes {
+		if hash.Algo == curseforgeHashSHA1 {
+			version.Checksums = util.Checksums{SHA1: hash.Value}
+		}
+	}
+
+	for _, dep := range f.Dependencies {
+		depType := ""
+		switch dep.RelationType {
+		case curseforgeRequired:
+			depType = "required"
+		case curseforgeOptional:
+			depType = "optional"
+		case curseforgeIncompatible:
+			depType = "incompatible"
+		case curseforgeEmbedded, curseforgeInclude:
+			depType = "embedded"
+		default:
+			continue // tools aren't installed
+		}
+		version.Dependencies = append(version.Dependencies, dependency{
+			ProjectID:      strconv.Itoa(dep.ModID),
+			DependencyType: depType,
+			source:         SourceCurseForge,
+		})
+	}
+	return version
+}
+
+// curseforgeFingerprint is CurseForge's file fingerprint: MurmurHash2 with seed 1
+// over the file's bytes, leaving out tabs, newlines, carriage returns and spaces
+func curseforgeFingerprint(path string) (uint32, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	normalized := make([]byte, 0, len(data))
+	for _, b := range data {
+		if b != 9 && b != 10 && b != 13 && b != 32 {
+			normalized = append(normalized, b)
+		}
+	}
+
+	const m = 0x5bd1e995
+	h := uint32(1) ^ uint32(len(normalized))
+
+	for len(normalized) >= 4 {
+		k := binary.LittleEndian.Uint32(normalized)
+		k *= m
+		k ^= k >> 24
+		k *= m
+		h = h*m ^ k
+		normalized = normalized[4:]
+	}
+
+	switch len(normalized) {
+	case 3:
+		h ^= uint32(normalized[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(normalized[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(normalized[0])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return h, nil
+}
+
+// getCurseForgeFilesByFingerprint looks up many files by their fingerprint in one request.
+// Files CurseForge doesn't know are missing from the result.
+func getCurseForgeFilesByFingerprint(fingerprints []uint32) (map[uint32]curseforgeFile, error) {
+	files := make(map[uint32]curseforgeFile)
+	if len(fingerprints) == 0 {
+		return files, nil
+	}
+
+	var response struct {
+		Data struct {
+			ExactMatches []struct {
+				File curseforgeFile `json:"file"`
+			} `json:"exactMatches"`
+		} `json:"data"`
+	}
+	path := "/fingerprints/" + strconv.Itoa(curseforgeMinecraft)
+	if err := curseforge.post(path, map[string]any{"fingerprints": fingerprints}, &response); err != nil {
+		return nil, fmt.Errorf("failed to look up files: %w", err)
+	}
+
+	for _, match := range response.Data.ExactMatches {
+		files[match.File.FileFingerprint] = match.File
+	}
+	return files, nil
+}
+
+// curseforgeMatch is a jar found on CurseForge by its fingerprint
+type curseforgeMatch struct {
+	slug    string
+	version projectVersion
+}
+
+// matchCurseForgeFiles looks jars up by their CurseForge fingerprint, keyed by path
+func matchCurseForgeFiles(jars []trackedJar) (map[string]curseforgeMatch, error) {
+	fingerprints := make([]uint32, len(jars))
+	for i, jar := range jars {
+		fingerprint, err := curseforgeFingerprint(jar.path)
+		if err != nil {
+			return nil, err
+		}
+		fingerprints[i] = fingerprint
+	}
+
+	files, err := getCurseForgeFilesByFingerprint(fingerprints)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for _, file := range files {
+		ids = append(ids, file.ModID)
+	}
+	mods, err := getCurseForgeMods(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make(map[string]curseforgeMatch)
+	for i, jar := range jars {
+		file, ok := files[fingerprints[i]]
+		if !ok {
+			continue
+		}
+		mod, ok := mods[file.ModID]
+		if !ok {
+			continue
+		}
+
+		version := file.projectVersion(mod)
+		version.Checksums.SHA1 = jar.sha1 // osmium.json pins the file on disk
+		matches[jar.path] = curseforgeMatch{slug: mod.Slug, version: version}
+	}
+	return matches, nil
+}
@@ -0,0 +1,354 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+	"github.com/limelamp/osmium/internal/util"
+)
+
+// errNoUpdates is returned for projects added from a URL or a file, which have nowhere to look for updates
+var errNoUpdates = errors.New("has no update source")
+
+// GitHubAPIURL is the base URL of the GitHub API. OSMIUM_GITHUB_API_URL overrides it.
+var GitHubAPIURL = "https://api.github.com"
+
+// github is the client used by every GitHub call
+var github = newAPIClient("GitHub", func() string {
+	return envURL("OSMIUM_GITHUB_API_URL", GitHubAPIURL)
+})
+
+func init() {
+	github.prepare = setGitHubToken
+}
+
+// githubReleasesPerRequest is how many releases are checked for a matching asset
+const githubReleasesPerRequest = 20
+
+var (
+	// githubRepoPattern matches owner/repo
+	githubRepoPattern = regexp.MustCompile(`^[\w.-]+/[\w.-]+$`)
+	// githubReleaseURL matches the download URL of a release asset: owner, repo, tag and file name
+	githubReleaseURL = regexp.MustCompile(`^https://github\.com/([\w.-]+)/([\w.-]+)/releases/download/([^/]+)/([^/]+)$`)
+	// fileVersion matches the version at the end of a jar name, e.g. "-1.2.3" in "MyPlugin-1.2.3"
+	fileVersion = regexp.MustCompile(`[-_+ ]v?(\d[\w.+-]*)$`)
+)
+
+// setGitHubToken authenticates with GITHUB_TOKEN when it's set, which raises the rate limit
+func setGitHubToken(req *http.Request) error {
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+type githubAsset struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+	Digest      string `json:"digest"` // "sha256:<hex>", missing on older assets
+}
+
+type githubRelease struct {
+	TagName    string        `json:"tag_name"`
+	Draft      bool          `json:"draft"`
+	Prerelease bool          `json:"prerelease"`
+	Assets     []githubAsset `json:"assets"`
+}
+
+// directSource is where a project added from a URL or a file came from, it has no versions to offer
+type directSource struct {
+	source string
+	origin string // shown in messages, e.g. "a URL"
+}
+
+func (s directSource) name() string {
+	return s.source
+}
+
+func (s directSource) search(query string, conf *config.OsmiumConfig, limit int) ([]SearchResult, error) {
+	return nil, fmt.Errorf("projects from %s can't be searched", s.origin)
+}
+
+func (s directSource) resolve(projectID string) (string, error) {
+	return "", fmt.Errorf("projects from %s are added with --url or --file", s.origin)
+}
+
+func (s directSource) versions(slug string, conf *config.OsmiumConfig) ([]projectVersion, error) {
+	return nil, fmt.Errorf("%w: it was added from %s, add it with --github owner/repo to follow its releases", errNoUpdates, s.origin)
+}
+
+// githubSource follows the releases of a GitHub repository. The repository and the asset glob are
+// recorded in osmium.json, releases say nothing about the loader or Minecraft version.
+type githubSource struct{}
+
+func (githubSource) name() string {
+	return SourceGitHub
+}
+
+func (githubSource) search(query string, conf *config.OsmiumConfig, limit int) ([]SearchResult, error) {
+	return nil, fmt.Errorf("GitHub releases can't be searched")
+}
+
+func (githubSource) resolve(projectID string) (string, error) {
+	return "", fmt.Errorf("GitHub releases are added with --github owner/repo")
+}
+
+func (githubSource) versions(slug string, conf *config.OsmiumConfig) ([]projectVersion, error) {
+	project, ok := conf.Mods[slug]
+	if !ok {
+		project, ok = conf.Plugins[slug]
+	}
+	if !ok || project.Repo == "" {
+		return nil, fmt.Errorf("%s has no GitHub repository in osmium.json", slug)
+	}
+
+	version, err := latestGitHubRelease(project.Repo, project.Asset)
+	if err != nil {
+		return nil, err
+	}
+	return []projectVersion{version}, nil
+}
+
+// latestGitHubRelease returns the newest stable release of repo with an asset matching the glob,
+// any jar when it's empty
+func latestGitHubRelease(repo, asset string) (projectVersion, error) {
+	if !githubRepoPattern.MatchString(repo) {
+		return projectVersion{}, fmt.Errorf("invalid GitHub repository %q, expected owner/repo", repo)
+	}
+
+	q := url.Values{}
+	q.Set("per_page", fmt.Sprint(githubReleasesPerRequest))
+
+	var releases []githubRelease
+	if err := github.get("/repos/"+repo+"/releases", q, &releases); err != nil {
+		return projectVersion{}, fmt.Errorf("failed to fetch releases: %w", err)
+	}
+
+	for _, release := range releases {
+		if release.Draft || release.Prerelease {
+			continue
+		}
+		for _, file := range release.Assets {
+			if !matchesAsset(file.Name, asset) || !safeFileName(file.Name) {
+				continue
+			}
+
+			version := projectVersion{
+				Source:        SourceGitHub,
+				ProjectID:     repo,
+				ID:            release.TagName,
+				VersionNumber: release.TagName,
+				FileName:      file.Name,
+				URL:           file.DownloadURL,
+				Repo:          repo,
+				Asset:         asset,
+			}
+			if sum, ok := strings.CutPrefix(file.Digest, "sha256:"); ok {
+				version.Checksums.SHA256 = sum
+			}
+			if version.Asset == "" {
+				version.Asset = assetPattern(file.Name, release.TagName)
+			}
+			return version, nil
+		}
+	}
+
+	if asset == "" {
+		asset = "*.jar"
+	}
+	return projectVersion{}, fmt.Errorf("%w: no release of %s has an asset matching %s", ErrIncompatible, repo, asset)
+}
+
+// matchesAsset reports whether a release asset matches the glob, any jar when it's empty.
+// The sources and javadoc jars some projects publish alongside never match.
+func matchesAsset(name, glob string) bool {
+	if strings.HasSuffix(name, "-sources.jar") || strings.HasSuffix(name, "-javadoc.jar") {
+		return false
+	}
+	if glob == "" {
+		return strings.HasSuffix(name, ".jar")
+	}
+	ok, _ := path.Match(glob, name)
+	return ok
+}
+
+// assetPattern turns the file name of a release asset into a glob matching it in later releases,
+// by replacing the version from the tag, e.g. "MyPlugin-1.2.3.jar" of v1.2.3 becomes "MyPlugin-*.jar"
+func assetPattern(fileName, tag string) string {
+	version := strings.TrimPrefix(strings.TrimPrefix(tag, "v"), "V")
+	if version != "" && strings.Contains(fileName, version) {
+		return strings.Replace(fileName, version, "*", 1)
+	}
+	return fileName
+}
+
+// splitFileName guesses the slug and version of a jar from its name, e.g. "MyPlugin-1.2.3.jar"
+// is myplugin 1.2.3
+func splitFileName(fileName string) (string, string) {
+	name := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	version := ""
+	if match := fileVersion.FindStringSubmatchIndex(name); match != nil && match[0] > 0 {
+		version = name[match[2]:match[3]]
+		name = name[:match[0]]
+	}
+	return strings.ToLower(strings.ReplaceAll(name, " ", "-")), version
+}
+
+// cacheOrigin puts the file a project was added from into the download cache, when it's still there,
+// so installing the project finds it by hash
+func cacheOrigin(origin string) {
+	if origin == "" {
+		return
+	}
+	if _, err := os.Stat(filepath.FromSlash(origin)); err == nil {
+		util.CacheFile(filepath.FromSlash(origin))
+	}
+}
+
+// safeFileName reports whether name can be written into a server folder as-is,
+// without separators or dot segments that would put it somewhere else
+func safeFileName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// originPath returns how osmium.json records the jar a project was added from: relative to the server,
+// so committed files don't name the machine's folders. Empty when there's no relative path (another drive).
+func originPath(file string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(wd, file)
+	if err != nil {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// DirectProject is a project that doesn't come from a project source: a jar at a URL, a local jar,
+// or the latest release of a GitHub repository
+type DirectProject struct {
+	URL   string // download URL of the jar
+	File  string // path of a local jar
+	Repo  string // owner/repo whose GitHub releases update the project
+	Asset string // glob of the release asset, derived from the file name when empty
+	Name  string // slug in osmium.json, derived from the file name when empty
+}
+
+// AddDirectProject installs a jar from a URL, a local file or GitHub releases and records it in osmium.json
+// with its hash and origin. GitHub release URLs follow the repository's releases on update, so does
+// anything added with a Repo.
+func AddDirectProject(project DirectProject, folder string) error {
+	osmiumConf, err := config.ReadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read osmium.json: %w", err)
+	}
+
+	// 1. Work out where the file comes from
+	var version projectVersion
+	localFile := ""
+	switch {
+	case project.File != "":
+		if localFile, err = filepath.Abs(project.File); err != nil {
+			return err
+		}
+		if info, err := os.Stat(localFile); err != nil {
+			return fmt.Errorf("failed to read %s: %w", project.File, err)
+		} else if info.IsDir() {
+			return fmt.Errorf("%s is a folder, expected a jar", project.File)
+		}
+		fileName := filepath.Base(localFile)
+		_, versionNumber := splitFileName(fileName)
+		version = projectVersion{Source: SourceFile, ProjectID: fileName, VersionNumber: versionNumber, FileName: fileName, Origin: originPath(localFile)}
+	case project.URL != "":
+		parsed, err := url.Parse(project.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return fmt.Errorf("invalid URL %q", project.URL)
+		}
+		// Path is already unescaped, unescaping again would turn %252F into a separator
+		fileName := path.Base(parsed.Path)
+		if !safeFileName(fileName) {
+			return fmt.Errorf("%s doesn't point at a file", project.URL)
+		}
+		_, versionNumber := splitFileName(fileName)
+		version = projectVersion{Source: SourceURL, ProjectID: project.URL, VersionNumber: versionNumber, FileName: fileName, URL: project.URL}
+
+		// Release assets know their repository and tag
+		if match := githubReleaseURL.FindStringSubmatch(project.URL); match != nil {
+			if project.Repo == "" {
+				project.Repo = match[1] + "/" + match[2]
+			}
+			version.VersionNumber = match[3]
+			if project.Asset == "" {
+				project.Asset = assetPattern(fileName, match[3])
+			}
+		}
+	case project.Repo != "":
+		if version, err = latestGitHubRelease(project.Repo, project.Asset); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("nothing to add: give a URL, a file or a GitHub repository")
+	}
+
+	if project.Repo != "" {
+		if !githubRepoPattern.MatchString(project.Repo) {
+			return fmt.Errorf("invalid GitHub repository %q, expected owner/repo", project.Repo)
+		}
+		version.Source = SourceGitHub
+		version.Repo = project.Repo
+		if project.Asset != "" {
+			version.Asset = project.Asset
+		}
+		if version.Asset == "" {
+			version.Asset = assetPattern(version.FileName, version.VersionNumber)
+		}
+	}
+
+	slug := project.Name
+	if slug == "" {
+		slug, _ = splitFileName(version.FileName)
+	}
+
+	// 2. Check if already installed
+	if isProjectInstalled(slug, folder, osmiumConf) {
+		fmt.Printf("%s already installed\n\n", slug)
+		return nil
+	}
+
+	// 3. Download the file, local ones go through the download cache so install can restore them
+	fmt.Printf("Downloading %s...\n\n", version.FileName)
+	if localFile != "" {
+		sums, err := util.CacheFile(localFile)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to the download cache: %w", version.FileName, err)
+		}
+		if err := util.DownloadCached("", filepath.Join(folder, version.FileName), sums); err != nil {
+			return err
+		}
+		version.Checksums = sums
+	} else if err := downloadVersion(&version, folder); err != nil {
+		return err
+	}
+
+	// 4. Update config
+	if err := updateConfigWithProject(slug, folder, version, osmiumConf); err != nil {
+		return fmt.Errorf("failed to update osmium.json: %w", err)
+	}
+
+	if version.Source == SourceGitHub {
+		fmt.Printf("✓ Added %s, updates come from the releases of %s (%s)\n", slug, version.Repo, version.Asset)
+	} else {
+		fmt.Printf("✓ Added %s, 'osmium update' skips it\n", slug)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package shared
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+	"github.com/limelamp/osmium/internal/tui/storage"
+	"github.com/limelamp/osmium/internal/util"
+)
+
+// Files written by ExportDocker, relative to the server folder
+const (
+	DockerfileName    = "Dockerfile"
+	ComposeFileName   = "docker-compose.yml"
+	DockerIgnoreName  = ".dockerignore"
+	dockerConfigDir   = ".osmium-docker" // servers.json copied into the image
+	dockerServerDir   = "/server"
+	dockerAppConfig   = "/config" // XDG_CONFIG_HOME inside the container
+	defaultServerPort = "25565"
+)
+
+// DockerExport describes the generated container setup of a server
+type DockerExport struct {
+	Name          string   // compose service and container name
+	JavaImage     string   // e.g. eclipse-temurin:21-jre
+	Port          string   // server-port from server.properties
+	Memory        string   // JVM heap, e.g. 4G
+	MemLimit      string   // container memory limit, heap plus JVM overhead
+	Volumes       []string // folders inside /server kept in named volumes
+	Ignored       []string // .dockerignore entries
+	OsmiumVersion string   // version of Osmium installed in the image, e.g. latest
+	Frozen        bool     // osmium.lock.json exists, the image installs exactly the locked versions
+}
+
+// dockerJavaImage returns the Temurin base image for a Minecraft version
+func dockerJavaImage(mcVersion string) string {
+	major := util.RequiredJavaMajor(mcVersion)
+	// There are no maintained Java 16 images, 1.17 runs fine on 17
+	if major == 16 {
+		major = 17
+	}
+	return fmt.Sprintf("eclipse-temurin:%d-jre", major)
+}
+
+// dockerMemLimit adds room for the JVM's own memory on top of the heap
+func dockerMemLimit(memory string) string {
+	megabytes := util.MemoryMegabytes(memory)
+	overhead := max(megabytes/4, 1024)
+	return fmt.Sprintf("%dm", megabytes+overhead)
+}
+
+// dockerServiceName turns a server name into a valid compose service name
+func dockerServiceName(name string) string {
+	service := strings.Trim(serviceNameUnsafe.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+	if service == "" {
+		return "minecraft"
+	}
+	return service
+}
+
+// PlanDockerExport collects what the container setup of the server in the current directory needs
+func PlanDockerExport(server storage.Server, osmiumVersion string) (DockerExport, error) {
+	osmiumConf, err := config.ReadConfig()
+	if err != nil {
+		return DockerExport{}, fmt.Errorf("failed to read osmium.json: %w", err)
+	}
+
+	memory, err := util.NormalizeMemory(server.Memory)
+	if err != nil {
+		return DockerExport{}, err
+	}
+
+	port := defaultServerPort
+	if properties, err := util.ReadServerProperties("server.properties"); err == nil && properties["server-port"] != "" {
+		port = properties["server-port"]
+	}
+
+	level := util.GetLevelName()
+	export := DockerExport{
+		Name:          dockerServiceName(server.Name),
+		JavaImage:     dockerJavaImage(osmiumConf.Version),
+		Port:          port,
+		Memory:        memory,
+		MemLimit:      dockerMemLimit(memory),
+		Volumes:       []string{level, level + "_nether", level + "_the_end", "config", "logs", BackupFolder},
+		OsmiumVersion: osmiumVersion,
+	}
+	if _, err := os.Stat(config.LockFileName); err == nil {
+		export.Frozen = true
+	}
+
+	// Worlds and runtime state live in volumes, tracked jars are fetched by 'osmium install' at build time.
+	var jars []string
+	for _, project := range osmiumConf.Mods {
+		jars = append(jars, "mods/"+project.FileName)
+	}
+	for _, project := range osmiumConf.Plugins {
+		jars = append(jars, "plugins/"+project.FileName)
+	}
+	sort.Strings(jars)
+
+	// config/ is copied in so the (empty) config volume starts out with the server's current configs
+	export.Ignored = []string{level, level + "_nether", level + "_the_end", "logs", BackupFolder}
+	export.Ignored = append(export.Ignored, RollbackFolder, LockFileName, ConsoleSocketName, "crash-reports", DockerfileName, ComposeFileName)
+	export.Ignored = append(export.Ignored, jars...)
+
+	return export, nil
+}
+
+// BuildDockerfile returns the Dockerfile for an export
+func BuildDockerfile(export DockerExport) string {
+	var b strings.Builder
+
+	b.WriteString("# Generated by 'osmium export docker'\n\n")
+	b.WriteString("# Osmium runs the server inside the container and installs the tracked mods/plugins\n")
+	b.WriteString("FROM golang:1.25 AS osmium\n")
+	fmt.Fprintf(&b, "RUN CGO_ENABLED=0 go install github.com/limelamp/osmium@%s\n\n", export.OsmiumVersion)
+
+	fmt.Fprintf(&b, "FROM %s\n", export.JavaImage)
+	b.WriteString("COPY --from=osmium /go/bin/osmium /usr/local/bin/osmium\n\n")
+	fmt.Fprintf(&b, "ENV XDG_CONFIG_HOME=%s\n", dockerAppConfig)
+	fmt.Fprintf(&b, "COPY %s/servers.json %s/osmium/servers.json\n\n", dockerConfigDir, dockerAppConfig)
+
+	fmt.Fprintf(&b, "WORKDIR %s\n", dockerServerDir)
+	b.WriteString("COPY . .\n")
+	if export.Frozen {
+		b.WriteString("# Fetch exactly the mods and plugins pinned in osmium.lock.json\n")
+		b.WriteString("RUN osmium install --frozen\n\n")
+	} else {
+		b.WriteString("# Fetch the mods and plugins listed in osmium.json\n")
+		b.WriteString("RUN osmium install\n\n")
+	}
+
+	fmt.Fprintf(&b, "EXPOSE %s\n", export.Port)
+	quoted := make([]string, len(export.Volumes))
+	for i, volume := range export.Volumes {
+		quoted[i] = fmt.Sprintf("%q", dockerServerDir+"/"+volume)
+	}
+	fmt.Fprintf(&b, "VOLUME [%s]\n\n", strings.Join(quoted, ", "))
+
+	b.WriteString("# 'osmium run' sends 'stop' on SIGTERM, so 'docker stop' saves the world\n")
+	b.WriteString("ENTRYPOINT [\"osmium\", \"run\", \"--stop-timeout\", \"60s\"]\n")
+
+	return b.String()
+}
+
+// dockerVolumeName turns a folder into a compose volume name
+func dockerVolumeName(folder string) string {
+	return strings.Trim(serviceNameUnsafe.ReplaceAllString(strings.ToLower(folder), "_"), "_.")
+}
+
+// BuildCompose returns the docker-compose.yml for an export
+func BuildCompose(export DockerExport) string {
+	var b strings.Builder
+
+	b.WriteString("# Generated by 'osmium export docker'\n")
+	b.WriteString("services:\n")
+	fmt.Fprintf(&b, "  %s:\n", export.Name)
+	b.WriteString("    build: .\n")
+	fmt.Fprintf(&b, "    container_name: %s\n", export.Name)
+	b.WriteString("    restart: unless-stopped\n")
+	b.WriteString("    stdin_open: true\n")
+	b.WriteString("    tty: true\n")
+	b.WriteString("    stop_grace_period: 90s\n")
+	fmt.Fprintf(&b, "    mem_limit: %s\n", export.MemLimit)
+	b.WriteString("    ports:\n")
+	fmt.Fprintf(&b, "      - \"%s:%s\"\n", export.Port, export.Port)
+	b.WriteString("    volumes:\n")
+	for _, volume := range export.Volumes {
+		fmt.Fprintf(&b, "      - %s:%s/%s\n", dockerVolumeName(volume), dockerServerDir, volume)
+	}
+	b.WriteString("\nvolumes:\n")
+	for _, volume := range export.Volumes {
+		fmt.Fprintf(&b, "  %s:\n", dockerVolumeName(volume))
+	}
+
+	return b.String()
+}
+
+// ExportDocker writes the Dockerfile, compose file, .dockerignore and the container's servers.json
+// into the current directory. Existing files are only replaced when force is set.
+func ExportDocker(server storage.Server, osmiumVersion string, force bool) ([]string, error) {
+	export, err := PlanDockerExport(server, osmiumVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if !force {
+		for _, name := range []string{DockerfileName, ComposeFileName} {
+			if _, err := os.Stat(name); err == nil {
+				return nil, fmt.Errorf("%s already exists, use --force to overwrite it", name)
+			}
+		}
+	}
+
+	// The container gets its own copy of the server entry, so JVM settings and scheduled tasks carry over
+	containerServer := server
+	containerServer.Path = dockerServerDir
+	containerServer.JavaPath = "" // the image's java is on PATH
+	data, err := json.MarshalIndent([]storage.Server{containerServer}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dockerConfigDir, 0755); err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{
+		DockerfileName:   BuildDockerfile(export),
+		ComposeFileName:  BuildCompose(export),
+		DockerIgnoreName: strings.Join(export.Ignored, "\n") + "\n",
+		filepath.Join(dockerConfigDir, "servers.json"): string(data),
+	}
+
+	var written []string
+	for _, name := range []string{DockerfileName, ComposeFileName, DockerIgnoreName, filepath.Join(dockerConfigDir, "servers.json")} {
+		if err := os.WriteFile(name, []byte(files[name]), 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		written = append(written, name)
+	}
+
+	return written, nil
+}
@@ -0,0 +1,189 @@
+package shared
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+	"github.com/limelamp/osmium/internal/util"
+)
+
+// HangarAPIURL is the base URL of PaperMC's Hangar API. OSMIUM_HANGAR_API_URL overrides it.
+var HangarAPIURL = "https://hangar.papermc.io/api/v1"
+
+// hangar is the client used by every Hangar call
+var hangar = newAPIClient("Hangar", func() string {
+	return envURL("OSMIUM_HANGAR_API_URL", HangarAPIURL)
+})
+
+// hangarPlatforms maps server loaders to the Hangar platform whose plugins they run
+var hangarPlatforms = map[string]string{
+	"paper":      "PAPER",
+	"purpur":     "PAPER",
+	"folia":      "PAPER",
+	"velocity":   "VELOCITY",
+	"waterfall":  "WATERFALL",
+	"bungeecord": "WATERFALL",
+}
+
+// hangarVersionsPerRequest is how many versions are checked for a compatible one
+const hangarVersionsPerRequest = 25
+
+type hangarProject struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	Namespace   struct {
+		Owner string `json:"owner"`
+		Slug  string `json:"slug"`
+	} `json:"namespace"`
+	Stats struct {
+		Downloads int `json:"downloads"`
+	} `json:"stats"`
+}
+
+// hangarDownload is the file of a version for one platform
+type hangarDownload struct {
+	FileInfo *struct {
+		Name   string `json:"name"`
+		SHA256 string `json:"sha256Hash"`
+	} `json:"fileInfo"`
+	ExternalURL string `json:"externalUrl"`
+	DownloadURL string `json:"downloadUrl"`
+}
+
+type hangarDependency struct {
+	Name        string `json:"name"`
+	Required    bool   `json:"required"`
+	ExternalURL string `json:"externalUrl"`
+}
+
+type hangarVersion struct {
+	Name               string                        `json:"name"`
+	Downloads          map[string]hangarDownload     `json:"downloads"`
+	PluginDependencies map[string][]hangarDependency `json:"pluginDependencies"`
+}
+
+// hangarSource installs plugins from PaperMC's Hangar
+type hangarSource struct{}
+
+func (hangarSource) name() string {
+	return SourceHangar
+}
+
+// hangarPlatform returns the Hangar platform of the configured loader
+func hangarPlatform(conf *config.OsmiumConfig) (string, error) {
+	platform, ok := hangarPlatforms[strings.ToLower(conf.Loader)]
+	if !ok {
+		return "", fmt.Errorf("%w: Hangar has no plugins for %s servers", ErrIncompatible, conf.Loader)
+	}
+	return platform, nil
+}
+
+func (hangarSource) search(query string, conf *config.OsmiumConfig, limit int) ([]SearchResult, error) {
+	platform, err := hangarPlatform(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Set("query", query)
+	q.Set("platform", platform)
+	q.Set("version", conf.Version)
+	q.Set("limit", strconv.Itoa(limit))
+
+	var response struct {
+		Result []hangarProject `json:"result"`
+	}
+	if err := hangar.get("/projects", q, &response); err != nil {
+		return nil, fmt.Errorf("failed to search Hangar: %w", err)
+	}
+
+	results := make([]SearchResult, len(response.Result))
+	for i, project := range response.Result {
+		results[i] = SearchResult{
+			ProjectID:   project.Namespace.Slug,
+			Slug:        project.Namespace.Slug,
+			Title:       project.Name,
+			Description: project.Description,
+			Categories:  []string{strings.ToLower(project.Category)},
+			Downloads:   project.Stats.Downloads,
+			ServerSide:  "required",
+		}
+	}
+	return results, nil
+}
+
+func (hangarSource) resolve(projectID string) (string, error) {
+	var project hangarProject
+	if err := hangar.get("/projects/"+url.PathEscape(projectID), nil, &project); err != nil {
+		return "", fmt.Errorf("failed to fetch project info: %w", err)
+	}
+	return project.Namespace.Slug, nil
+}
+
+func (hangarSource) versions(slug string, conf *config.OsmiumConfig) ([]projectVersion, error) {
+	platform, err := hangarPlatform(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Set("platform", platform)
+	q.Set("platformVersion", conf.Version)
+	q.Set("limit", strconv.Itoa(hangarVersionsPerRequest))
+
+	var response struct {
+		Result []hangarVersion `json:"result"`
+	}
+	if err := hangar.get("/projects/"+url.PathEscape(slug)+"/versions", q, &response); err != nil {
+		return nil, fmt.Errorf("failed to fetch versions: %w", err)
+	}
+
+	var versions []projectVersion
+	for _, version := range response.Result {
+		download, ok := version.Downloads[platform]
+		if !ok {
+			continue
+		}
+		versions = append(versions, version.projectVersion(slug, platform, download))
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w for Minecraft %s", ErrIncompatible, conf.Version)
+	}
+	return versions, nil
+}
+
+// projectVersion converts a Hangar version for one platform. Hangar only publishes SHA256 hashes,
+// and refers to dependencies by project name.
+func (v hangarVersion) projectVersion(slug, platform string, download hangarDownload) projectVersion {
+	version := projectVersion{
+		Source:        SourceHangar,
+		ProjectID:     slug,
+		ID:            v.Name,
+		VersionNumber: v.Name,
+		URL:           download.DownloadURL,
+		ExternalURL:   download.ExternalURL,
+	}
+	if download.FileInfo != nil {
+		version.FileName = download.FileInfo.Name
+		version.Checksums = util.Checksums{SHA256: download.FileInfo.SHA256}
+	}
+
+	for _, dep := range v.PluginDependencies[platform] {
+		depType := "optional"
+		if dep.Required {
+			depType = "required"
+		}
+		version.Dependencies = append(version.Dependencies, dependency{
+			ProjectID:      dep.Name,
+			DependencyType: depType,
+			source:         SourceHangar,
+			externalURL:    dep.ExternalURL,
+		})
+	}
+	return version
+}
@@ -0,0 +1,130 @@
+package shared
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+	"github.com/limelamp/osmium/internal/tui/storage"
+	"github.com/limelamp/osmium/internal/util"
+)
+
+// GetRunOptions returns the JVM options of the server registered for the current directory,
+// or the defaults when the directory isn't a registered server.
+func GetRunOptions() util.RunOptions {
+	dir, err := os.Getwd()
+	if err != nil {
+		return util.RunOptions{}
+	}
+
+	server, err := storage.NewServerStore().FindByPath(dir)
+	if err != nil {
+		return util.RunOptions{}
+	}
+
+	return util.RunOptions{
+		Memory:   server.Memory,
+		Profile:  server.JVMProfile,
+		Flags:    server.JVMFlags,
+		JavaPath: server.JavaPath,
+	}
+}
+
+// SaveRunOptions stores the memory, JVM profile and java of the server registered for the current
+// directory, so 'osmium run' launches with them and writes them to user_jvm_args.txt.
+// Directories that aren't registered servers have nowhere to keep them and are left alone.
+func SaveRunOptions(opts util.RunOptions) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	store := storage.NewServerStore()
+	server, err := store.FindByPath(dir)
+	if err != nil {
+		return nil
+	}
+
+	memory, err := util.NormalizeMemory(opts.Memory)
+	if err != nil {
+		return err
+	}
+
+	return store.Update(server.ID, func(s *storage.Server) error {
+		s.Memory = memory
+		s.JVMProfile = opts.Profile
+		s.JavaPath = opts.JavaPath
+		return nil
+	})
+}
+
+// ServerRunCommand builds the launch command for the server in the current directory
+// with its JVM profile applied, keeping user_jvm_args.txt in sync for Forge and NeoForge.
+func ServerRunCommand(loader string) (string, []string, error) {
+	opts := GetRunOptions()
+
+	jvmArgs, err := util.JVMArgs(opts)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if loader == "Forge" || loader == "NeoForge" {
+		if err := util.WriteUserJVMArgs(jvmArgs); err != nil {
+			return "", nil, err
+		}
+	}
+
+	javaPath, args := util.GetServerRunCommand(loader, opts.JavaPath, jvmArgs)
+	return javaPath, args, nil
+}
+
+// CheckServerJava checks the java the server in the current directory will use against its
+// Minecraft version and returns a warning, or an empty string when they're compatible.
+func CheckServerJava() string {
+	osmiumConf, err := config.ReadConfig()
+	if err != nil || osmiumConf.Version == "" {
+		return ""
+	}
+
+	javaPath := GetRunOptions().JavaPath
+	if javaPath == "" {
+		javaPath, err = exec.LookPath("java")
+		if err != nil {
+			return fmt.Sprintf("java was not found on PATH, Minecraft %s needs Java %d (see 'osmium java')",
+				osmiumConf.Version, util.RequiredJavaMajor(osmiumConf.Version))
+		}
+	}
+
+	rt, err := util.GetJavaRuntime(javaPath)
+	if err != nil {
+		return err.Error()
+	}
+	return util.CheckJavaCompatibility(rt, osmiumConf.Version)
+}
+
+// NewServerCommand prepares the server process for the current directory, with output going to output.
+// Java compatibility problems are written to output as a warning before the server starts.
+func NewServerCommand(loader string, output io.Writer) (*exec.Cmd, error) {
+	javaPath, args, err := ServerRunCommand(loader)
+	if err != nil {
+		return nil, err
+	}
+
+	if warning := CheckServerJava(); warning != "" {
+		fmt.Fprintf(output, "⚠ %s\n", warning)
+	}
+
+	cmd := exec.Command(javaPath, args...)
+	cmd.Dir, _ = os.Getwd()
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	// Forge/NeoForge run scripts call plain `java`, point them at the pinned runtime
+	if pinned := GetRunOptions().JavaPath; pinned != "" {
+		cmd.Env = util.JavaEnv(pinned)
+	}
+
+	return cmd, nil
+}
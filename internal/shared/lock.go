@@ -0,0 +1,353 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+)
+
+/*
+	osmium.json says which projects a server has, osmium.lock.json pins how they were resolved:
+	the exact version IDs, file URLs and hashes, and which projects each one requires.
+	The lock is kept in sync whenever a project is added, updated, tracked, removed or migrated,
+	and 'osmium install --frozen' installs exactly that set, so staging and production match.
+*/
+
+const lockFileVersion = 1
+
+// lockedProject builds the lock entry of a project version
+func lockedProject(version projectVersion) config.LockedProject {
+	locked := config.LockedProject{
+		ProjectID:     version.ProjectID,
+		VersionID:     version.ID,
+		VersionNumber: version.VersionNumber,
+		FileName:      version.FileName,
+		DownloadURL:   version.URL,
+		SHA1:          version.Checksums.SHA1,
+		SHA256:        version.Checksums.SHA256,
+		SHA512:        version.Checksums.SHA512,
+		Origin:        version.Origin,
+	}
+	if version.Source != SourceModrinth {
+		locked.Source = version.Source
+	}
+	if version.URL == "" {
+		locked.ManualURL = version.ExternalURL
+	}
+
+	for _, dep := range version.Dependencies {
+		if dep.DependencyType == "required" && dep.ProjectID != "" && dep.externalURL == "" {
+			locked.Dependencies = append(locked.Dependencies, dep.ProjectID)
+		}
+	}
+	sort.Strings(locked.Dependencies)
+
+	return locked
+}
+
+// newLock returns an empty lock for a config
+func newLock(conf *config.OsmiumConfig) *config.LockFile {
+	return &config.LockFile{
+		LockVersion: lockFileVersion,
+		Loader:      conf.Loader,
+		Version:     conf.Version,
+		Mods:        make(map[string]config.LockedProject),
+		Plugins:     make(map[string]config.LockedProject),
+	}
+}
+
+// lockedVersion is a project version to pin in the lock
+type lockedVersion struct {
+	folder  string
+	slug    string
+	version projectVersion
+}
+
+// recordLock pins the given versions in osmium.lock.json and drops entries osmium.json
+// no longer tracks. The lock is created if there is none yet.
+func recordLock(conf *config.OsmiumConfig, versions ...lockedVersion) error {
+	lock, err := config.ReadLock()
+	if os.IsNotExist(err) {
+		lock = newLock(conf)
+	} else if err != nil {
+		return err
+	}
+
+	lock.LockVersion = lockFileVersion
+	lock.Loader = conf.Loader
+	lock.Version = conf.Version
+
+	for _, locked := range versions {
+		if locked.version.FileName == "" {
+			continue
+		}
+		switch locked.folder {
+		case "mods":
+			lock.Mods[locked.slug] = lockedProject(locked.version)
+		case "plugins":
+			lock.Plugins[locked.slug] = lockedProject(locked.version)
+		}
+	}
+
+	for slug := range lock.Mods {
+		if _, ok := conf.Mods[slug]; !ok {
+			delete(lock.Mods, slug)
+		}
+	}
+	for slug := range lock.Plugins {
+		if _, ok := conf.Plugins[slug]; !ok {
+			delete(lock.Plugins, slug)
+		}
+	}
+
+	return config.WriteLock(lock)
+}
+
+// LockProjects rewrites osmium.lock.json from the versions pinned in osmium.json. Modrinth files are
+// looked up by their SHA1 so nothing gets upgraded, other sources are locked as osmium.json pins them.
+func LockProjects() (int, error) {
+	osmiumConf, err := config.ReadConfig()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read osmium.json: %w", err)
+	}
+
+	var hashes []string
+	for _, projects := range []map[string]config.Project{osmiumConf.Mods, osmiumConf.Plugins} {
+		for _, project := range projects {
+			if project.SHA1 != "" && sourceName(project) == SourceModrinth {
+				hashes = append(hashes, strings.ToLower(project.SHA1))
+			}
+		}
+	}
+
+	// The dependencies of other sources can't be looked up by hash, they're kept from the old lock
+	oldLock, err := config.ReadLock()
+	if err != nil {
+		oldLock = newLock(osmiumConf)
+	}
+
+	versions, err := getVersionsByHash(hashes)
+	if err != nil {
+		return 0, err
+	}
+
+	lock := newLock(osmiumConf)
+	var problems []error
+
+	for _, folder := range []string{"mods", "plugins"} {
+		projects, locks, oldLocks := osmiumConf.Mods, lock.Mods, oldLock.Mods
+		if folder == "plugins" {
+			projects, locks, oldLocks = osmiumConf.Plugins, lock.Plugins, oldLock.Plugins
+		}
+
+		for _, slug := range sortedSlugs(projects) {
+			project := projects[slug]
+			if project.SHA1 == "" {
+				problems = append(problems, fmt.Errorf("%s has no SHA1 in osmium.json", slug))
+				continue
+			}
+
+			if sourceName(project) != SourceModrinth {
+				locked, ok := oldLocks[slug]
+				if !ok || !strings.EqualFold(locked.SHA1, project.SHA1) {
+					locked = config.LockedProject{Source: project.Source, ProjectID: slug, VersionID: project.VersionNumber}
+				}
+				locked.VersionNumber = project.VersionNumber
+				locked.FileName = project.FileName
+				locked.DownloadURL = project.DownloadURL
+				locked.ManualURL = project.ManualURL
+				locked.Origin = project.Origin
+				locked.SHA1 = project.SHA1
+				locks[slug] = locked
+				continue
+			}
+
+			version, ok := versions[strings.ToLower(project.SHA1)]
+			if !ok || len(version.Files) == 0 {
+				problems = append(problems, fmt.Errorf("%s: %s isn't known to Modrinth", slug, project.FileName))
+				continue
+			}
+
+			// The version can hold several files, lock the one osmium.json refers to
+			preferFile(&version, project.SHA1)
+			locks[slug] = lockedProject(version.projectVersion())
+		}
+	}
+
+	if len(problems) > 0 {
+		return 0, errors.Join(problems...)
+	}
+
+	if err := config.WriteLock(lock); err != nil {
+		return 0, err
+	}
+	return len(lock.Mods) + len(lock.Plugins), nil
+}
+
+// checkLock compares osmium.lock.json with osmium.json and the project folders,
+// returning every difference that keeps a frozen install from being exact
+func checkLock(conf *config.OsmiumConfig, lock *config.LockFile) []error {
+	var problems []error
+
+	if !strings.EqualFold(lock.Loader, conf.Loader) || lock.Version != conf.Version {
+		problems = append(problems, fmt.Errorf("lock is for %s %s but osmium.json is %s %s", lock.Loader, lock.Version, conf.Loader, conf.Version))
+	}
+
+	lockedIDs := make(map[string]bool)
+	for _, locked := range lock.Mods {
+		lockedIDs[locked.ProjectID] = true
+	}
+	for _, locked := range lock.Plugins {
+		lockedIDs[locked.ProjectID] = true
+	}
+
+	for _, folder := range []string{"mods", "plugins"} {
+		projects, locks := conf.Mods, lock.Mods
+		if folder == "plugins" {
+			projects, locks = conf.Plugins, lock.Plugins
+		}
+
+		for slug, project := range projects {
+			locked, ok := locks[slug]
+			if !ok {
+				problems = append(problems, fmt.Errorf("%s is in osmium.json but not locked", slug))
+			} else if !strings.EqualFold(locked.SHA1, project.SHA1) {
+				problems = append(problems, fmt.Errorf("%s is %s in osmium.json but %s in the lock", slug, project.VersionNumber, locked.VersionNumber))
+			}
+		}
+
+		// Every jar in the folder has to be a locked file with the locked contents
+		expected := make(map[string]bool)
+		for slug, locked := range locks {
+			if _, ok := projects[slug]; !ok {
+				problems = append(problems, fmt.Errorf("%s is locked but not in osmium.json", slug))
+			}
+			for _, dep := range locked.Dependencies {
+				if !lockedIDs[dep] {
+					problems = append(problems, fmt.Errorf("%s requires project %s, which isn't locked", slug, dep))
+				}
+			}
+
+			path := filepath.Join(folder, locked.FileName)
+			expected[path] = true
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				continue // downloaded below
+			}
+			if checksum, err := calculateSHA1(path); err != nil {
+				problems = append(problems, err)
+			} else if !strings.EqualFold(checksum, locked.SHA1) {
+				problems = append(problems, fmt.Errorf("%s doesn't match the locked %s %s", path, slug, locked.VersionNumber))
+			}
+		}
+		for _, jar := range listJars(folder) {
+			if !expected[jar] {
+				problems = append(problems, fmt.Errorf("%s isn't in the lock, remove it or add it with 'osmium track'", jar))
+			}
+		}
+	}
+
+	return problems
+}
+
+// InstallFrozen installs exactly the projects in osmium.lock.json. Nothing is resolved against
+// Modrinth: the lock has to agree with osmium.json and the project folders, and every download
+// has to match its locked hash, otherwise nothing is changed and the differences are returned.
+func InstallFrozen() (int, error) {
+	osmiumConf, err := config.ReadConfig()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read osmium.json: %w", err)
+	}
+
+	lock, err := config.ReadLock()
+	if os.IsNotExist(err) {
+		return 0, fmt.Errorf("%s not found, create it with 'osmium lock'", config.LockFileName)
+	} else if err != nil {
+		return 0, err
+	}
+
+	// Step 1: Verify everything before touching the folders
+	if problems := checkLock(osmiumConf, lock); len(problems) > 0 {
+		return 0, fmt.Errorf("%s doesn't match this server:\n%w", config.LockFileName, errors.Join(problems...))
+	}
+
+	// Step 2: Download the missing files, each checked against its locked hashes
+	type missingFile struct {
+		slug   string
+		target string
+		locked config.LockedProject
+	}
+
+	var missing []missingFile
+	for _, folder := range []string{"mods", "plugins"} {
+		locks := lock.Mods
+		if folder == "plugins" {
+			locks = lock.Plugins
+		}
+
+		slugs := make([]string, 0, len(locks))
+		for slug := range locks {
+			slugs = append(slugs, slug)
+		}
+		sort.Strings(slugs)
+
+		for _, slug := range slugs {
+			target := filepath.Join(folder, locks[slug].FileName)
+			if _, err := os.Stat(target); err == nil {
+				continue // already checked in step 1
+			}
+			missing = append(missing, missingFile{slug: slug, target: target, locked: locks[slug]})
+		}
+	}
+
+	// Downloads go to a staging folder next to mods/ and plugins/, they're only moved in once all succeeded
+	staging, err := os.MkdirTemp(".", ".osmium-frozen-")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create staging folder: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	err = forEachOrdered(len(missing), func(i int, out io.Writer) error {
+		entry := missing[i]
+		if entry.locked.DownloadURL == "" && entry.locked.ManualURL != "" {
+			return &ManualDownloadError{Project: entry.slug, FileName: entry.locked.FileName, URL: entry.locked.ManualURL, Folder: filepath.Dir(entry.target)}
+		}
+		fmt.Fprintf(out, "Downloading %s %s...\n", entry.slug, entry.locked.VersionNumber)
+		cacheOrigin(entry.locked.Origin)
+
+		file := mrpackFile{
+			Path:      filepath.ToSlash(entry.target),
+			Hashes:    map[string]string{"sha1": entry.locked.SHA1},
+			Downloads: []string{entry.locked.DownloadURL},
+		}
+		if entry.locked.SHA256 != "" {
+			file.Hashes["sha256"] = entry.locked.SHA256
+		}
+		if entry.locked.SHA512 != "" {
+			file.Hashes["sha512"] = entry.locked.SHA512
+		}
+		if err := downloadPackFile(file, filepath.Join(staging, entry.target)); err != nil {
+			return fmt.Errorf("failed to install %s: %w", entry.slug, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%w\nnothing was installed", err)
+	}
+
+	// Step 3: Move the verified files in
+	for _, entry := range missing {
+		if err := os.MkdirAll(filepath.Dir(entry.target), 0755); err != nil {
+			return 0, err
+		}
+		if err := os.Rename(filepath.Join(staging, entry.target), entry.target); err != nil {
+			return 0, fmt.Errorf("failed to install %s: %w", entry.slug, err)
+		}
+	}
+	return len(missing), nil
+}
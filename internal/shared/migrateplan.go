@@ -0,0 +1,171 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+)
+
+// Statuses a tracked project can have in a migration plan
+const (
+	PlanUpgrade      = "upgrade"
+	PlanUnchanged    = "unchanged"
+	PlanIncompatible = "incompatible"
+	PlanError        = "error"
+)
+
+// MigrationPlanEntry describes what migrate would do with one tracked project
+type MigrationPlanEntry struct {
+	Slug           string `json:"slug"`
+	Folder         string `json:"folder"`
+	CurrentVersion string `json:"current_version"`
+	NewVersion     string `json:"new_version,omitempty"`
+	NewFile        string `json:"new_file,omitempty"`
+	Status         string `json:"status"`
+	Error          string `json:"error,omitempty"`
+}
+
+// MigrationDependency is a project that migrate would pull in as a dependency
+type MigrationDependency struct {
+	ProjectID  string   `json:"project_id"`
+	Slug       string   `json:"slug"`
+	Type       string   `json:"dependency_type"`
+	Folder     string   `json:"folder"`
+	RequiredBy []string `json:"required_by"`
+}
+
+// MigrationPlan is the dry-run report of a migration
+type MigrationPlan struct {
+	FromLoader        string                `json:"from_loader"`
+	FromVersion       string                `json:"from_version"`
+	ToLoader          string                `json:"to_loader"`
+	ToVersion         string                `json:"to_version"`
+	ReplaceServerJar  bool                  `json:"replace_server_jar"`
+	Projects          []MigrationPlanEntry  `json:"projects"`
+	AddedDependencies []MigrationDependency `json:"added_dependencies"`
+}
+
+// planProjects checks every project of one folder against the target config
+func planProjects(folder string, projects map[string]config.Project, newConf *config.OsmiumConfig, plan *MigrationPlan, deps map[string]*MigrationDependency) {
+	slugs := make([]string, 0, len(projects))
+	for slug := range projects {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	for _, slug := range slugs {
+		current := projects[slug]
+		entry := MigrationPlanEntry{
+			Slug:           slug,
+			Folder:         folder,
+			CurrentVersion: current.VersionNumber,
+		}
+
+		var versions []projectVersion
+		source, err := getSource(current.Source)
+		if err == nil {
+			versions, err = source.versions(slug, newConf)
+		}
+
+		switch {
+		case errors.Is(err, errNoUpdates):
+			entry.Status = PlanUnchanged
+			entry.Error = err.Error()
+		case errors.Is(err, ErrIncompatible):
+			entry.Status = PlanIncompatible
+		case err != nil:
+			entry.Status = PlanError
+			entry.Error = err.Error()
+		case versions[0].FileName == "":
+			entry.Status = PlanIncompatible
+			entry.Error = "no files found in the latest version"
+		default:
+			latest := versions[0]
+			entry.NewVersion = latest.VersionNumber
+			entry.NewFile = latest.FileName
+
+			entry.Status = PlanUpgrade
+			if isLatestVersion(current, latest) {
+				entry.Status = PlanUnchanged
+			}
+
+			for _, dep := range latest.Dependencies {
+				depFolder, err := getDependencyFolder(folder, dep.DependencyType)
+				if err != nil {
+					continue // incompatible/embedded dependencies aren't installed
+				}
+
+				if existing, ok := deps[dep.ProjectID]; ok {
+					existing.RequiredBy = append(existing.RequiredBy, slug)
+					continue
+				}
+
+				deps[dep.ProjectID] = &MigrationDependency{
+					ProjectID:  dep.ProjectID,
+					Type:       dep.DependencyType,
+					Folder:     depFolder,
+					RequiredBy: []string{slug},
+				}
+			}
+		}
+
+		plan.Projects = append(plan.Projects, entry)
+	}
+}
+
+// PlanMigration queries the source of every tracked project against the target loader/version
+// without downloading or changing anything.
+func PlanMigration(loader string, version string) (MigrationPlan, error) {
+	oldConf, err := config.ReadConfig()
+	if err != nil {
+		return MigrationPlan{}, fmt.Errorf("failed to read osmium.json: %w", err)
+	}
+
+	newConf := &config.OsmiumConfig{
+		Category: oldConf.Category,
+		Loader:   loader,
+		Version:  version,
+		Mods:     oldConf.Mods,
+		Plugins:  oldConf.Plugins,
+	}
+
+	plan := MigrationPlan{
+		FromLoader:       oldConf.Loader,
+		FromVersion:      oldConf.Version,
+		ToLoader:         loader,
+		ToVersion:        version,
+		ReplaceServerJar: !strings.EqualFold(oldConf.Loader, loader) || oldConf.Version != version,
+	}
+
+	deps := make(map[string]*MigrationDependency)
+	planProjects("mods", oldConf.Mods, newConf, &plan, deps)
+	planProjects("plugins", oldConf.Plugins, newConf, &plan, deps)
+
+	// Resolve dependency slugs and drop the ones that are already tracked
+	for id, dep := range deps {
+		info, err := getProjectInfo(id)
+		if err != nil {
+			dep.Slug = id
+		} else {
+			dep.Slug = info.Slug
+		}
+
+		if _, ok := oldConf.Mods[dep.Slug]; ok {
+			continue
+		}
+		if _, ok := oldConf.Plugins[dep.Slug]; ok {
+			continue
+		}
+
+		plan.AddedDependencies = append(plan.AddedDependencies, *dep)
+	}
+
+	sort.Slice(plan.AddedDependencies, func(i, j int) bool {
+		return plan.AddedDependencies[i].Slug < plan.AddedDependencies[j].Slug
+	})
+
+	return plan, nil
+}
@@ -0,0 +1,110 @@
+package shared
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+	"github.com/limelamp/osmium/internal/tui/constants"
+)
+
+// ModrinthAPIURL is the base URL of the Modrinth API. OSMIUM_MODRINTH_API_URL overrides it.
+var ModrinthAPIURL = "https://api.modrinth.com/v2"
+
+// modrinth is the client used by every Modrinth call
+var modrinth = newAPIClient("Modrinth", func() string {
+	return envURL("OSMIUM_MODRINTH_API_URL", ModrinthAPIURL)
+})
+
+// modrinthSource installs projects from Modrinth
+type modrinthSource struct{}
+
+func (modrinthSource) name() string {
+	return SourceModrinth
+}
+
+// searchFacets narrows a search to projects that run on the server: plugins or mods (never modpacks,
+// which share the loader categories), a compatible loader, the server's Minecraft version, and required
+// or optional on the server side. Facets in the same inner list are OR'd, the lists themselves are AND'd.
+func searchFacets(conf *config.OsmiumConfig) [][]string {
+	projectType := "project_type:mod"
+	if slices.Contains(constants.PLUGIN_LOADERS, strings.ToLower(conf.Loader)) {
+		projectType = "project_type:plugin"
+	}
+
+	var loaders []string
+	for _, loader := range compatibleLoaders(conf) {
+		loaders = append(loaders, "categories:"+loader)
+	}
+
+	return [][]string{
+		{projectType},
+		loaders,
+		{"versions:" + conf.Version},
+		{"server_side:required", "server_side:optional"},
+	}
+}
+
+func (modrinthSource) search(query string, conf *config.OsmiumConfig, limit int) ([]SearchResult, error) {
+	facets, err := json.Marshal(searchFacets(conf))
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Set("query", query)
+	q.Set("facets", string(facets))
+	q.Set("index", "relevance")
+	q.Set("limit", strconv.Itoa(limit))
+
+	var response struct {
+		Hits []SearchResult `json:"hits"`
+	}
+	if err := modrinth.get("/search", q, &response); err != nil {
+		return nil, fmt.Errorf("failed to search Modrinth: %w", err)
+	}
+
+	return response.Hits, nil
+}
+
+func (modrinthSource) resolve(projectID string) (string, error) {
+	info, err := getProjectInfo(projectID)
+	if err != nil {
+		return "", err
+	}
+	return info.Slug, nil
+}
+
+func (modrinthSource) versions(slug string, conf *config.OsmiumConfig) ([]projectVersion, error) {
+	versions, err := getProjectVersions(slug, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	converted := make([]projectVersion, len(versions))
+	for i, version := range versions {
+		converted[i] = version.projectVersion()
+	}
+	return converted, nil
+}
+
+// projectVersion converts a Modrinth version, installing its first file
+func (v modrinthVersion) projectVersion() projectVersion {
+	version := projectVersion{
+		Source:        SourceModrinth,
+		ProjectID:     v.ProjectID,
+		ID:            v.ID,
+		VersionNumber: v.VersionNumber,
+		Dependencies:  v.Dependencies,
+	}
+	if len(v.Files) > 0 {
+		version.FileName = v.Files[0].Filename
+		version.URL = v.Files[0].URL
+		version.Checksums = v.Files[0].checksums()
+	}
+	return version
+}
@@ -0,0 +1,51 @@
+package shared
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+)
+
+func TestSearchFacets(t *testing.T) {
+	tests := []struct {
+		loader      string
+		projectType string
+	}{
+		{loader: "Fabric", projectType: "project_type:mod"},
+		{loader: "Forge", projectType: "project_type:mod"},
+		{loader: "Quilt", projectType: "project_type:mod"},
+		{loader: "Paper", projectType: "project_type:plugin"},
+		{loader: "Velocity", projectType: "project_type:plugin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.loader, func(t *testing.T) {
+			var facets [][]string
+			fakeModrinth(t, func(w http.ResponseWriter, r *http.Request) {
+				if err := json.Unmarshal([]byte(r.URL.Query().Get("facets")), &facets); err != nil {
+					t.Errorf("facets aren't JSON: %v", err)
+				}
+				w.Write([]byte(`{"hits":[]}`))
+			})
+
+			conf := &config.OsmiumConfig{Loader: tt.loader, Version: "1.21.1"}
+			if _, err := (modrinthSource{}).search("map", conf, 10); err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.ContainsFunc(facets, func(group []string) bool {
+				return slices.Equal(group, []string{tt.projectType})
+			}) {
+				t.Errorf("facets %v don't restrict the search to %s", facets, tt.projectType)
+			}
+			if !slices.ContainsFunc(facets, func(group []string) bool {
+				return slices.Equal(group, []string{"versions:1.21.1"})
+			}) {
+				t.Errorf("facets %v don't restrict the search to the server's version", facets)
+			}
+		})
+	}
+}
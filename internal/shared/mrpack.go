@@ -0,0 +1,581 @@
+package shared
+
+import (
+	"archive/zip"
+	"cmp"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+	"github.com/limelamp/osmium/internal/tui/constants"
+	"github.com/limelamp/osmium/internal/util"
+)
+
+const mrpackIndexFile = "modrinth.index.json"
+
+// mrpackLoaders maps the dependency keys of a modpack index to Osmium loader names
+var mrpackLoaders = map[string]string{
+	"fabric-loader": "Fabric",
+	"quilt-loader":  "Quilt",
+	"forge":         "Forge",
+	"neoforge":      "NeoForge",
+}
+
+// mrpackFile is one downloadable file of a modpack
+type mrpackFile struct {
+	Path      string            `json:"path"`
+	Hashes    map[string]string `json:"hashes"`
+	Env       *mrpackEnv        `json:"env,omitempty"`
+	Downloads []string          `json:"downloads"`
+	FileSize  int64             `json:"fileSize"`
+}
+
+// mrpackEnv says whether a file is required, optional or unsupported on each side
+type mrpackEnv struct {
+	Client string `json:"client"`
+	Server string `json:"server"`
+}
+
+// mrpackIndex is the modrinth.index.json of a modpack
+type mrpackIndex struct {
+	FormatVersion int               `json:"formatVersion"`
+	Game          string            `json:"game"`
+	VersionID     string            `json:"versionId"`
+	Name          string            `json:"name"`
+	Summary       string            `json:"summary,omitempty"`
+	Files         []mrpackFile      `json:"files"`
+	Dependencies  map[string]string `json:"dependencies"`
+}
+
+// MrpackImport summarizes an imported modpack
+type MrpackImport struct {
+	Name       string
+	Loader     string
+	Version    string
+	Downloaded int
+	Skipped    int // client-only files
+	Overrides  int
+}
+
+// safePackPath checks that a path from a modpack stays inside the server folder
+func safePackPath(path string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(path))
+	if filepath.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(os.PathSeparator)) {
+		return "", fmt.Errorf("unsafe path in modpack: %s", path)
+	}
+	return cleaned, nil
+}
+
+// readMrpackIndex reads and validates modrinth.index.json from an opened pack
+func readMrpackIndex(pack *zip.ReadCloser) (mrpackIndex, error) {
+	for _, f := range pack.File {
+		if f.Name != mrpackIndexFile {
+			continue
+		}
+
+		reader, err := f.Open()
+		if err != nil {
+			return mrpackIndex{}, err
+		}
+		defer reader.Close()
+
+		var index mrpackIndex
+		if err := json.NewDecoder(reader).Decode(&index); err != nil {
+			return mrpackIndex{}, fmt.Errorf("failed to parse %s: %w", mrpackIndexFile, err)
+		}
+		if index.Game != "minecraft" {
+			return mrpackIndex{}, fmt.Errorf("modpack is for %q, not minecraft", index.Game)
+		}
+		return index, nil
+	}
+
+	return mrpackIndex{}, fmt.Errorf("%s not found, is this a .mrpack file?", mrpackIndexFile)
+}
+
+// packLoader returns the Osmium loader and Minecraft version from the index dependencies.
+// Packs listing more than one loader are refused, a server runs exactly one.
+func packLoader(index mrpackIndex) (string, string, string, error) {
+	mcVersion := index.Dependencies["minecraft"]
+	if mcVersion == "" {
+		return "", "", "", fmt.Errorf("modpack doesn't specify a Minecraft version")
+	}
+
+	var keys []string
+	for key := range mrpackLoaders {
+		if _, ok := index.Dependencies[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	switch len(keys) {
+	case 0:
+		return "Vanilla", "", mcVersion, nil
+	case 1:
+		return mrpackLoaders[keys[0]], index.Dependencies[keys[0]], mcVersion, nil
+	default:
+		return "", "", "", fmt.Errorf("modpack lists more than one loader (%s), a server can only run one", strings.Join(keys, ", "))
+	}
+}
+
+// downloadPackFile downloads a modpack file, trying each mirror until one matches the expected hash
+func downloadPackFile(file mrpackFile, target string) error {
+	if file.Hashes["sha512"] == "" && file.Hashes["sha1"] == "" {
+		return fmt.Errorf("%s has no sha1 or sha512 hash", file.Path)
+	}
+	sums := util.Checksums{SHA1: file.Hashes["sha1"], SHA256: file.Hashes["sha256"], SHA512: file.Hashes["sha512"]}
+
+	var lastErr error
+	for _, url := range file.Downloads {
+		if lastErr = util.DownloadCached(url, target, sums); lastErr == nil {
+			return nil
+		}
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("%s has no download URLs", file.Path)
+	}
+	return lastErr
+}
+
+// applyOverrides copies a folder of the pack (overrides/ or server-overrides/) into the server folder
+func applyOverrides(pack *zip.ReadCloser, prefix string) (int, error) {
+	count := 0
+	for _, f := range pack.File {
+		if !strings.HasPrefix(f.Name, prefix+"/") || f.FileInfo().IsDir() {
+			continue
+		}
+
+		target, err := safePackPath(strings.TrimPrefix(f.Name, prefix+"/"))
+		if err != nil {
+			return count, err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return count, err
+		}
+
+		in, err := f.Open()
+		if err != nil {
+			return count, err
+		}
+		// Replace rather than overwrite, the target can be a hardlink into the download cache
+		os.Remove(target)
+		out, err := os.Create(target)
+		if err != nil {
+			in.Close()
+			return count, err
+		}
+		_, err = io.Copy(out, in)
+		in.Close()
+		out.Close()
+		if err != nil {
+			return count, fmt.Errorf("failed to extract %s: %w", f.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// ImportMrpack sets up a server in the current directory from a Modrinth modpack:
+// server-side files are downloaded and verified, overrides are applied, osmium.json
+// gets the pack's loader and version, and the server jar is installed.
+func ImportMrpack(packPath string) (MrpackImport, error) {
+	if _, err := os.Stat("osmium.json"); err == nil {
+		return MrpackImport{}, fmt.Errorf("osmium.json already exists, import the modpack into an empty folder")
+	}
+
+	pack, err := zip.OpenReader(packPath)
+	if err != nil {
+		return MrpackImport{}, fmt.Errorf("failed to open %s: %w", packPath, err)
+	}
+	defer pack.Close()
+
+	index, err := readMrpackIndex(pack)
+	if err != nil {
+		return MrpackImport{}, err
+	}
+
+	loader, loaderVersion, mcVersion, err := packLoader(index)
+	if err != nil {
+		return MrpackImport{}, err
+	}
+
+	result := MrpackImport{Name: index.Name, Loader: loader, Version: mcVersion}
+	fmt.Printf("Importing %s %s (%s %s)\n\n", index.Name, index.VersionID, loader, mcVersion)
+
+	// Step 1: Download server-side files
+	var files []mrpackFile
+	var targets []string
+	for _, file := range index.Files {
+		if file.Env != nil && file.Env.Server == "unsupported" {
+			result.Skipped++
+			continue
+		}
+
+		target, err := safePackPath(file.Path)
+		if err != nil {
+			return result, err
+		}
+		files = append(files, file)
+		targets = append(targets, target)
+	}
+
+	downloaded := make([]bool, len(files))
+	err = forEachOrdered(len(files), func(i int, out io.Writer) error {
+		fmt.Fprintf(out, "Downloading %s...\n", files[i].Path)
+		if err := downloadPackFile(files[i], targets[i]); err != nil {
+			return err
+		}
+		downloaded[i] = true
+		return nil
+	})
+	for _, ok := range downloaded {
+		if ok {
+			result.Downloaded++
+		}
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// Step 2: Overrides, server-overrides win over the shared ones
+	for _, prefix := range []string{"overrides", "server-overrides"} {
+		count, err := applyOverrides(pack, prefix)
+		if err != nil {
+			return result, err
+		}
+		result.Overrides += count
+	}
+
+	// Step 3: osmium.json
+	category := constants.CategoryModLoader
+	if loader == "Vanilla" {
+		category = constants.CategoryVanilla
+	}
+	osmiumConf := &config.OsmiumConfig{
+		Category: category,
+		Loader:   loader,
+		Version:  mcVersion,
+		Mods:     make(map[string]config.Project),
+		Plugins:  make(map[string]config.Project),
+	}
+	if err := config.WriteConfig(osmiumConf); err != nil {
+		return result, err
+	}
+
+	// Step 4: Server jar
+	fmt.Println("\n--- Installing server ---")
+	if loaderVersion != "" {
+		fmt.Printf("⚠ The pack was built with %s %s, Osmium installs the latest %s for Minecraft %s\n", loader, loaderVersion, loader, mcVersion)
+	}
+	if err := util.DownloadJar(loader, mcVersion); err != nil {
+		return result, fmt.Errorf("failed to download server files: %w", err)
+	}
+
+	// Step 5: Track the downloaded mods so update/migrate know about them
+	fmt.Println("\n--- Tracking mods ---")
+	if err := TrackProjects(); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// MrpackExportOptions controls the modpack built by ExportMrpack
+type MrpackExportOptions struct {
+	Name          string   // pack name
+	Version       string   // pack version, e.g. "1.0.0"
+	Output        string   // .mrpack file to write
+	Overrides     []string // folders copied into overrides/, e.g. config
+	LoaderVersion string   // loader version for the index, detected from libraries/ when empty
+}
+
+// loaderLibraries is where each loader keeps its versioned library folders after installation
+var loaderLibraries = map[string]string{
+	"Fabric":   "libraries/net/fabricmc/fabric-loader",
+	"Quilt":    "libraries/org/quiltmc/quilt-loader",
+	"Forge":    "libraries/net/minecraftforge/forge",
+	"NeoForge": "libraries/net/neoforged/neoforge",
+}
+
+// detectLoaderVersion reads the installed loader version from the libraries folder
+func detectLoaderVersion(loader, mcVersion string) (string, error) {
+	folder, ok := loaderLibraries[loader]
+	if !ok {
+		return "", fmt.Errorf("%s is not a mod loader modpacks support", loader)
+	}
+
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return "", fmt.Errorf("couldn't detect the %s version (start the server once, or pass --loader-version)", loader)
+	}
+
+	// Several versions only happen after upgrades, the newest one is in use
+	version := ""
+	for _, entry := range entries {
+		if entry.IsDir() && (version == "" || compareVersions(entry.Name(), version) > 0) {
+			version = entry.Name()
+		}
+	}
+	if version == "" {
+		return "", fmt.Errorf("couldn't detect the %s version (start the server once, or pass --loader-version)", loader)
+	}
+
+	// Forge folders are named <minecraft>-<forge>
+	return strings.TrimPrefix(version, mcVersion+"-"), nil
+}
+
+// compareVersions compares dotted versions like "0.16.5" and "0.9.3" part by part, numbers by value,
+// and returns -1, 0 or 1
+func compareVersions(a, b string) int {
+	split := func(r rune) bool { return r == '.' || r == '-' || r == '+' || r == '_' }
+	aParts, bParts := strings.FieldsFunc(a, split), strings.FieldsFunc(b, split)
+
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum != bNum {
+				return cmp.Compare(aNum, bNum)
+			}
+		case aParts[i] != bParts[i]:
+			return strings.Compare(aParts[i], bParts[i])
+		}
+	}
+	return cmp.Compare(len(aParts), len(bParts))
+}
+
+// hashFile returns the sha1 and sha512 of a file and its size
+func hashFile(path string) (string, string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", "", 0, err
+	}
+	defer file.Close()
+
+	sha1Hash, sha512Hash := sha1.New(), sha512.New()
+	size, err := io.Copy(io.MultiWriter(sha1Hash, sha512Hash), file)
+	if err != nil {
+		return "", "", 0, err
+	}
+	return hex.EncodeToString(sha1Hash.Sum(nil)), hex.EncodeToString(sha512Hash.Sum(nil)), size, nil
+}
+
+// addOverrides adds a server folder to the pack below overrides/
+func addOverrides(writer *zip.Writer, folder string) (int, error) {
+	count := 0
+	err := filepath.WalkDir(folder, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := writer.Create("overrides/" + filepath.ToSlash(path))
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// mrpackDownloadHosts are the hosts the modpack format allows downloads from
+var mrpackDownloadHosts = []string{"cdn.modrinth.com", "github.com", "raw.githubusercontent.com", "gitlab.com"}
+
+// mrpackDownloadAllowed reports whether a modpack may list the URL as a download. CurseForge files,
+// manual downloads and jars added from a URL or disk aren't, players have to get those themselves.
+func mrpackDownloadAllowed(downloadURL string) bool {
+	parsed, err := url.Parse(downloadURL)
+	if err != nil || parsed.Scheme != "https" {
+		return false
+	}
+	return slices.Contains(mrpackDownloadHosts, parsed.Hostname())
+}
+
+// modSides looks up which sides the tracked Modrinth mods run on, for the env of their pack entries.
+// Mods from other sources, unknown sides and failed lookups get no env, which means required on both.
+func modSides(mods map[string]config.Project) map[string]*mrpackEnv {
+	if util.Offline {
+		return nil
+	}
+
+	slugs := make(map[string]string) // SHA1 to slug
+	var hashes []string
+	for slug, project := range mods {
+		if sourceName(project) == SourceModrinth && project.SHA1 != "" {
+			slugs[strings.ToLower(project.SHA1)] = slug
+			hashes = append(hashes, strings.ToLower(project.SHA1))
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	versions, err := getVersionsByHash(hashes)
+	if err != nil {
+		fmt.Printf("⚠ Couldn't look up which side the mods run on, they're required on both: %v\n", err)
+		return nil
+	}
+	var ids []string
+	for _, version := range versions {
+		ids = append(ids, version.ProjectID)
+	}
+	projects, err := getProjectsByID(ids)
+	if err != nil {
+		fmt.Printf("⚠ Couldn't look up which side the mods run on, they're required on both: %v\n", err)
+		return nil
+	}
+
+	known := []string{"required", "optional", "unsupported"}
+	envs := make(map[string]*mrpackEnv)
+	for sha1Sum, version := range versions {
+		info, ok := projects[version.ProjectID]
+		if ok && slices.Contains(known, info.ClientSide) && slices.Contains(known, info.ServerSide) {
+			envs[slugs[sha1Sum]] = &mrpackEnv{Client: info.ClientSide, Server: info.ServerSide}
+		}
+	}
+	return envs
+}
+
+// ExportMrpack builds a Modrinth modpack from the mods tracked in osmium.json, so players
+// can install a client matching the server. The files are checked against the stored SHA1s.
+func ExportMrpack(opts MrpackExportOptions) (count int, err error) {
+	osmiumConf, err := config.ReadConfig()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read osmium.json: %w", err)
+	}
+	if len(osmiumConf.Mods) == 0 {
+		return 0, fmt.Errorf("osmium.json has no tracked mods to export")
+	}
+
+	loaderVersion := opts.LoaderVersion
+	if loaderVersion == "" {
+		if loaderVersion, err = detectLoaderVersion(osmiumConf.Loader, osmiumConf.Version); err != nil {
+			return 0, err
+		}
+	}
+
+	loaderKey := ""
+	for key, loader := range mrpackLoaders {
+		if loader == osmiumConf.Loader {
+			loaderKey = key
+		}
+	}
+	if loaderKey == "" {
+		return 0, fmt.Errorf("%s is not a mod loader modpacks support", osmiumConf.Loader)
+	}
+
+	index := mrpackIndex{
+		FormatVersion: 1,
+		Game:          "minecraft",
+		VersionID:     opts.Version,
+		Name:          opts.Name,
+		Dependencies: map[string]string{
+			"minecraft": osmiumConf.Version,
+			loaderKey:   loaderVersion,
+		},
+	}
+
+	// Step 1: Index the tracked mods from their local files
+	sides := modSides(osmiumConf.Mods)
+	slugs := make([]string, 0, len(osmiumConf.Mods))
+	for slug := range osmiumConf.Mods {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	for _, slug := range slugs {
+		project := osmiumConf.Mods[slug]
+		if !mrpackDownloadAllowed(project.DownloadURL) {
+			reason := "it has no download URL"
+			if parsed, err := url.Parse(project.DownloadURL); err == nil && project.DownloadURL != "" {
+				reason = "it downloads from " + parsed.Host
+			}
+			fmt.Printf("⚠ Skipping %s, modpacks can only download from Modrinth and GitHub and %s\n", slug, reason)
+			continue
+		}
+
+		sha1Sum, sha512Sum, size, err := hashFile(filepath.Join("mods", project.FileName))
+		if err != nil {
+			return 0, fmt.Errorf("%s is tracked but can't be read, run 'osmium install' first: %w", slug, err)
+		}
+		if project.SHA1 != "" && !strings.EqualFold(project.SHA1, sha1Sum) {
+			return 0, fmt.Errorf("mods/%s doesn't match the version in osmium.json, run 'osmium install' or 'osmium track'", project.FileName)
+		}
+
+		index.Files = append(index.Files, mrpackFile{
+			Path:      "mods/" + project.FileName,
+			Hashes:    map[string]string{"sha1": sha1Sum, "sha512": sha512Sum},
+			Env:       sides[slug],
+			Downloads: []string{project.DownloadURL},
+			FileSize:  size,
+		})
+	}
+
+	// Step 2: Write the pack, a failed export doesn't leave a broken file behind
+	out, err := os.Create(opts.Output)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(opts.Output)
+		}
+	}()
+
+	writer := zip.NewWriter(out)
+
+	indexWriter, err := writer.Create(mrpackIndexFile)
+	if err != nil {
+		return 0, err
+	}
+	encoder := json.NewEncoder(indexWriter)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(index); err != nil {
+		return 0, err
+	}
+
+	// Step 3: Overrides
+	for _, folder := range opts.Overrides {
+		cleaned, err := safePackPath(folder)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := os.Stat(cleaned); os.IsNotExist(err) {
+			fmt.Printf("⚠ Override folder %s doesn't exist, skipping\n", folder)
+			continue
+		}
+		if _, err := addOverrides(writer, cleaned); err != nil {
+			return 0, fmt.Errorf("failed to add overrides from %s: %w", folder, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return 0, err
+	}
+
+	return len(index.Files), nil
+}
@@ -0,0 +1,40 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPackLoader(t *testing.T) {
+	tests := []struct {
+		name          string
+		dependencies  map[string]string
+		loader        string
+		loaderVersion string
+		wantErr       string
+	}{
+		{name: "fabric", dependencies: map[string]string{"minecraft": "1.21.1", "fabric-loader": "0.16.14"}, loader: "Fabric", loaderVersion: "0.16.14"},
+		{name: "neoforge", dependencies: map[string]string{"minecraft": "1.21.1", "neoforge": "21.1.77"}, loader: "NeoForge", loaderVersion: "21.1.77"},
+		{name: "vanilla", dependencies: map[string]string{"minecraft": "1.21.1"}, loader: "Vanilla"},
+		{name: "no minecraft version", dependencies: map[string]string{"fabric-loader": "0.16.14"}, wantErr: "doesn't specify a Minecraft version"},
+		{name: "two loaders", dependencies: map[string]string{"minecraft": "1.21.1", "quilt-loader": "0.26.4", "fabric-loader": "0.16.14"}, wantErr: "more than one loader (fabric-loader, quilt-loader)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, loaderVersion, mcVersion, err := packLoader(mrpackIndex{Dependencies: tt.dependencies})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loader != tt.loader || loaderVersion != tt.loaderVersion || mcVersion != "1.21.1" {
+				t.Errorf("got %s %s for %s, want %s %s for 1.21.1", loader, loaderVersion, mcVersion, tt.loader, tt.loaderVersion)
+			}
+		})
+	}
+}
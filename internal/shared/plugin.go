@@ -3,15 +3,14 @@ package shared
 import (
 	"crypto/sha1"
 	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
-	"time"
 
 	"github.com/limelamp/osmium/internal/tui/config"
 	"github.com/limelamp/osmium/internal/tui/constants"
@@ -32,66 +31,59 @@ import (
 */
 
 type projectInfo struct {
-	Slug    string   `json:"slug"`
-	Loaders []string `json:"loaders"`
+	ID         string   `json:"id"`
+	Slug       string   `json:"slug"`
+	Loaders    []string `json:"loaders"`
+	Categories []string `json:"categories"`
+	ClientSide string   `json:"client_side"` // required, optional, unsupported or unknown
+	ServerSide string   `json:"server_side"`
 }
 
 type dependency struct {
 	ProjectID      string `json:"project_id"`
+	VersionID      string `json:"version_id"` // set when the dependency needs that exact version
 	DependencyType string `json:"dependency_type"`
+
+	source      string // empty means Modrinth
+	externalURL string // set when the dependency isn't on any source and has to be downloaded manually
+}
+
+// modrinthFile is one file of a Modrinth version
+type modrinthFile struct {
+	URL      string `json:"url"`
+	Filename string `json:"filename"`
+	Primary  bool   `json:"primary"`
+	Hashes   struct {
+		SHA1   string `json:"sha1"`
+		SHA512 string `json:"sha512"`
+	} `json:"hashes"`
+}
+
+// checksums returns the hashes a download of the file is verified against
+func (f modrinthFile) checksums() util.Checksums {
+	return util.Checksums{SHA1: f.Hashes.SHA1, SHA512: f.Hashes.SHA512}
 }
 
 // The structure of the Modrinth Version API response
 type modrinthVersion struct {
-	ProjectID     string   `json:"project_id"`
-	VersionNumber string   `json:"version_number"`
-	GameVersions  []string `json:"game_versions"`
-	Loaders       []string `json:"loaders"`
-	VersionType   string   `json:"version_type"` // "release", "beta", "alpha"
-	Files         []struct {
-		URL      string `json:"url"`
-		Filename string `json:"filename"`
-		Primary  bool   `json:"primary"`
-		Hashes   struct {
-			SHA1 string `json:"sha1"`
-		} `json:"hashes"`
-	} `json:"files"`
-	Dependencies []dependency `json:"dependencies"`
-}
-
-var modrinthHTTPClient = &http.Client{Timeout: 30 * time.Second}
+	ID            string         `json:"id"`
+	ProjectID     string         `json:"project_id"`
+	VersionNumber string         `json:"version_number"`
+	GameVersions  []string       `json:"game_versions"`
+	Loaders       []string       `json:"loaders"`
+	VersionType   string         `json:"version_type"` // "release", "beta", "alpha"
+	Files         []modrinthFile `json:"files"`
+	Dependencies  []dependency   `json:"dependencies"`
+}
 
 //# --- project functions ---
 
-// doModrinthRequest performs an HTTP request with required Modrinth headers
-func doModrinthRequest(url string) (*http.Response, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("User-Agent", "Osmium-Manager/1.0")
-	return modrinthHTTPClient.Do(req)
-}
-
 // getProjectInfo fetches the project info (slug and loaders) from Modrinth API by project ID
 func getProjectInfo(projectID string) (projectInfo, error) {
-	slugUrl := fmt.Sprintf("https://api.modrinth.com/v2/project/%s", projectID)
-
-	resp, err := doModrinthRequest(slugUrl)
-	if err != nil {
-		return projectInfo{}, fmt.Errorf("failed to fetch slug: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return projectInfo{}, fmt.Errorf("failed to fetch project info: %s", resp.Status)
-	}
-
 	var info projectInfo
-	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
-		return projectInfo{}, fmt.Errorf("failed to decode slug response: %w", err)
+	if err := modrinth.get("/project/"+url.PathEscape(projectID), nil, &info); err != nil {
+		return projectInfo{}, fmt.Errorf("failed to fetch project info: %w", err)
 	}
-
 	return info, nil
 }
 
@@ -117,102 +109,104 @@ func isProjectInstalled(slug, folder string, conf *config.OsmiumConfig) bool {
 	}
 }
 
-// buildVersionQueryURL constructs the Modrinth version query URL with filters
-func buildVersionQueryURL(slug string, conf *config.OsmiumConfig) (string, error) {
-	baseUrl := fmt.Sprintf("https://api.modrinth.com/v2/project/%s/version", slug)
-
-	projectUrl, err := url.Parse(baseUrl)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse base url: %w", err)
-	}
-
-	q := projectUrl.Query()
-	q.Set("game_versions", fmt.Sprintf(`["%s"]`, conf.Version))
-
-	// Get compatible loaders
+// compatibleLoaders returns the Modrinth loaders whose projects run on the configured loader
+func compatibleLoaders(conf *config.OsmiumConfig) []string {
 	loaders, ok := constants.PLUGIN_RESOLVER[strings.ToLower(conf.Loader)]
 	if !ok {
 		loaders = []string{strings.ToLower(conf.Loader)}
 	}
-	q.Set("loaders", `["`+strings.Join(loaders, `","`)+`"]`)
+	return loaders
+}
 
-	projectUrl.RawQuery = q.Encode()
-	return projectUrl.String(), nil
+// versionQuery returns the filters of a version listing for the configured loader and version
+func versionQuery(conf *config.OsmiumConfig) url.Values {
+	q := url.Values{}
+	q.Set("game_versions", fmt.Sprintf(`["%s"]`, conf.Version))
+	q.Set("loaders", `["`+strings.Join(compatibleLoaders(conf), `","`)+`"]`)
+	return q
 }
 
 // getProjectVersions fetches compatible versions from Modrinth API
 func getProjectVersions(slug string, conf *config.OsmiumConfig) ([]modrinthVersion, error) {
-	projectUrl, err := buildVersionQueryURL(slug, conf)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := doModrinthRequest(projectUrl)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch versions: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch versions: %s", resp.Status)
-	}
-
 	var versions []modrinthVersion
-	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := modrinth.get("/project/"+url.PathEscape(slug)+"/version", versionQuery(conf), &versions); err != nil {
+		return nil, fmt.Errorf("failed to fetch versions: %w", err)
 	}
 
 	if len(versions) == 0 {
-		return nil, fmt.Errorf("no compatible versions found for Minecraft %s", conf.Version)
+		return nil, fmt.Errorf("%w for Minecraft %s", ErrIncompatible, conf.Version)
 	}
 
 	return versions, nil
 }
 
-// downloadFile downloads a file from URL to the specified folder
-func downloadFile(url, folder, filename string) error {
-	resp, err := modrinthHTTPClient.Get(url)
-	if err != nil {
-		return fmt.Errorf("failed to download file: %w", err)
+// installPinnedProject installs the version osmium.json pins for a project without asking Modrinth,
+// which works offline when the file is in the download cache
+func installPinnedProject(slug, folder string, conf *config.OsmiumConfig, out io.Writer) error {
+	project, ok := trackedProject(slug, folder, conf)
+	if !ok || project.SHA1 == "" {
+		return fmt.Errorf("%s has no pinned version in osmium.json", slug)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download file: %s", resp.Status)
+	if project.DownloadURL == "" && project.ManualURL != "" {
+		return &ManualDownloadError{Project: slug, FileName: project.FileName, URL: project.ManualURL, Folder: folder}
 	}
+	cacheOrigin(project.Origin)
 
-	// Ensure folder exists
-	if err := os.MkdirAll(folder, 0755); err != nil {
-		return fmt.Errorf("failed to create %s folder: %w", folder, err)
+	sums := util.Checksums{SHA1: project.SHA1}
+	if lock, err := config.ReadLock(); err == nil {
+		locks := lock.Mods
+		if folder == "plugins" {
+			locks = lock.Plugins
+		}
+		if locked, ok := locks[slug]; ok && strings.EqualFold(locked.SHA1, project.SHA1) {
+			sums.SHA256 = locked.SHA256
+			sums.SHA512 = locked.SHA512
+		}
 	}
 
-	filePath := filepath.Join(folder, filename)
-	out, err := os.Create(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+	fmt.Fprintf(out, "Installing %s %s...\n\n", slug, project.VersionNumber)
+	return util.DownloadCached(project.DownloadURL, filepath.Join(folder, project.FileName), sums)
+}
+
+// updateConfigWithProject adds the project to the appropriate section in config
+func updateConfigWithProject(slug, folder string, version projectVersion, conf *config.OsmiumConfig) error {
+	if err := setConfigProject(slug, folder, version, conf); err != nil {
+		return err
 	}
-	defer out.Close()
 
-	_, err = io.Copy(out, resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+	if err := config.WriteConfig(conf); err != nil {
+		return err
 	}
 
-	return nil
+	if folder != "mods" && folder != "plugins" {
+		return nil
+	}
+	return recordLock(conf, lockedVersion{folder: folder, slug: slug, version: version})
 }
 
-// updateConfigWithProject adds the project to the appropriate section in config
-func updateConfigWithProject(slug, folder string, version modrinthVersion, conf *config.OsmiumConfig) error {
-	if len(version.Files) == 0 {
+// setConfigProject puts the project into the config without writing it, for batches that write once
+func setConfigProject(slug, folder string, version projectVersion, conf *config.OsmiumConfig) error {
+	if version.FileName == "" {
 		return fmt.Errorf("no files found in version")
 	}
 
-	fileInfo := version.Files[0]
 	project := config.Project{
 		VersionNumber: version.VersionNumber,
-		FileName:      fileInfo.Filename,
-		DownloadURL:   fileInfo.URL,
-		SHA1:          fileInfo.Hashes.SHA1,
+		FileName:      version.FileName,
+		DownloadURL:   version.URL,
+		SHA1:          version.Checksums.SHA1,
+		Origin:        version.Origin,
+		Repo:          version.Repo,
+		Asset:         version.Asset,
+	}
+	if version.Source != SourceModrinth {
+		project.Source = version.Source // Modrinth is the default, existing entries stay unchanged
+	}
+	if version.URL == "" {
+		project.ManualURL = version.ExternalURL
+	}
+	if existing, ok := trackedProject(slug, folder, conf); ok {
+		project.DependencyOf = existing.DependencyOf // a new version doesn't change who needs it
 	}
 
 	switch folder {
@@ -223,7 +217,18 @@ func updateConfigWithProject(slug, folder string, version modrinthVersion, conf
 		// optional folders don't get tracked in config
 	}
 
-	return config.WriteConfig(conf)
+	return nil
+}
+
+// preferFile moves the file with the given SHA1 to the front of a version's files,
+// so a version looked up by hash refers to the file that's actually on disk
+func preferFile(version *modrinthVersion, sha1 string) {
+	for i, file := range version.Files {
+		if strings.EqualFold(file.Hashes.SHA1, sha1) {
+			version.Files[0], version.Files[i] = version.Files[i], version.Files[0]
+			return
+		}
+	}
 }
 
 // getDependencyFolder determines the correct folder for a dependency
@@ -244,30 +249,22 @@ func getDependencyFolder(parentFolder string, depType string) (string, error) {
 	}
 }
 
-// installDependencies recursively installs all dependencies
-func installDependencies(deps []dependency, parentFolder string) error {
-	for _, dep := range deps {
-		depFolder, err := getDependencyFolder(parentFolder, dep.DependencyType)
-		if err != nil {
-			return err
-		}
-
-		fmt.Printf("Installing dependency %s (%s) in %s\n",
-			dep.ProjectID, dep.DependencyType, depFolder)
-
-		if err := AddProjectByID(dep.ProjectID, depFolder); err != nil {
-			return fmt.Errorf("failed to install dependency %s: %w", dep.ProjectID, err)
-		}
-	}
-	return nil
-}
-
 //# --- Main Public Functions ---
 
 // AddProjectByID downloads and installs a mod/plugin from Modrinth by project ID
 func AddProjectByID(projectID string, folder string) error {
-	// 1. Get project info
-	info, err := getProjectInfo(projectID)
+	return AddProject(SourceModrinth, projectID, folder)
+}
+
+// AddProject downloads and installs a mod/plugin from a source ("modrinth", "hangar", empty means Modrinth)
+func AddProject(sourceName, projectID, folder string) error {
+	source, err := getSource(sourceName)
+	if err != nil {
+		return err
+	}
+
+	// 1. Get project info, client-only mods are refused
+	slug, err := resolveForServer(source, projectID)
 	if err != nil {
 		return err
 	}
@@ -278,41 +275,70 @@ func AddProjectByID(projectID string, folder string) error {
 		return fmt.Errorf("failed to read osmium.json: %w", err)
 	}
 
-	if isProjectInstalled(info.Slug, folder, osmiumConf) {
-		fmt.Printf("%s already installed\n\n", info.Slug)
-		return nil
+	if isProjectInstalled(slug, folder, osmiumConf) {
+		fmt.Printf("%s already installed\n\n", slug)
+		return markExplicit(slug, folder, osmiumConf)
 	}
 
-	// 3. Get compatible versions
-	versions, err := getProjectVersions(info.Slug, osmiumConf)
+	// 3. Get compatible versions and resolve what they pull in, conflicts stop before anything is downloaded
+	versions, err := source.versions(slug, osmiumConf)
 	if err != nil {
 		return err
 	}
 
-	// 4. Download the latest version
 	latestVersion := versions[0]
-	if len(latestVersion.Files) == 0 {
-		return fmt.Errorf("no files found in the latest version")
+	resolver := newDependencyResolver(osmiumConf)
+	resolver.addRoot(slug, folder, latestVersion)
+	if err := resolver.check(); err != nil {
+		return err
 	}
 
-	fileInfo := latestVersion.Files[0]
-	fmt.Printf("Downloading %s...\n\n", fileInfo.Filename)
+	// 4. Download the latest version
+	fmt.Printf("Downloading %s...\n\n", latestVersion.FileName)
 
-	if err := downloadFile(fileInfo.URL, folder, fileInfo.Filename); err != nil {
-		return err
+	// Files that can't be downloaded automatically are still tracked, they're listed on every install
+	if err := downloadVersion(&latestVersion, folder); err != nil {
+		var manual *ManualDownloadError
+		if !errors.As(err, &manual) {
+			return err
+		}
+		manual.Project = slug
+		fmt.Printf("⚠ %v\n\n", manual)
 	}
 
-	// 5. Update config
-	if err := updateConfigWithProject(info.Slug, folder, latestVersion, osmiumConf); err != nil {
+	// 5. Update config, then install the dependencies
+	if err := setConfigProject(slug, folder, latestVersion, osmiumConf); err != nil {
+		return fmt.Errorf("failed to update osmium.json: %w", err)
+	}
+	locked, depErr := resolver.install()
+	if err := config.WriteConfig(osmiumConf); err != nil {
 		return fmt.Errorf("failed to update osmium.json: %w", err)
 	}
 
-	// 6. Install dependencies
-	if err := installDependencies(latestVersion.Dependencies, folder); err != nil {
+	locked = append(locked, lockedVersion{folder: folder, slug: slug, version: latestVersion})
+	if err := recordLock(osmiumConf, locked...); err != nil {
 		return err
 	}
+	return depErr
+}
 
-	return nil
+// markExplicit turns a project that was pulled in as a dependency into one that was added directly
+func markExplicit(slug, folder string, conf *config.OsmiumConfig) error {
+	if folder != "mods" && folder != "plugins" {
+		return nil
+	}
+	project, ok := trackedProject(slug, folder, conf)
+	if !ok || len(project.DependencyOf) == 0 {
+		return nil
+	}
+
+	project.DependencyOf = nil
+	if folder == "mods" {
+		conf.Mods[slug] = project
+	} else {
+		conf.Plugins[slug] = project
+	}
+	return config.WriteConfig(conf)
 }
 
 func RemoveProjectByID(projectID string, folder string) error {
@@ -347,7 +373,7 @@ func RemoveProjectByID(projectID string, folder string) error {
 		return fmt.Errorf("failed to update osmium.json: %w", err)
 	}
 
-	return nil
+	return recordLock(osmiumConf)
 }
 
 // calculateSHA1 calculates the SHA1 checksum of a file
@@ -366,117 +392,172 @@ func calculateSHA1(filePath string) (string, error) {
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
+// getVersion fetches a Modrinth version by its ID
+func getVersion(versionID string) (modrinthVersion, error) {
+	var version modrinthVersion
+	if err := modrinth.get("/version/"+url.PathEscape(versionID), nil, &version); err != nil {
+		return modrinthVersion{}, fmt.Errorf("failed to fetch version %s: %w", versionID, err)
+	}
+	return version, nil
+}
+
 func getProjectByHash(hash string) (modrinthVersion, error) {
-	projectUrl := fmt.Sprintf("https://api.modrinth.com/v2/version_file/%s", hash)
+	var project modrinthVersion
+	if err := modrinth.get("/version_file/"+hash, nil, &project); err != nil {
+		return modrinthVersion{}, fmt.Errorf("failed to fetch version by hash: %w", err)
+	}
+	return project, nil
+}
 
-	resp, err := doModrinthRequest(projectUrl)
+// trackedJar is a jar found by TrackProjects
+type trackedJar struct {
+	folder string
+	path   string
+	sha1   string
+}
+
+// TrackProjects reads the plugins and mods directories and adds the jars Modrinth knows to osmium.json,
+// or CurseForge when there's an API key. The jars are hashed concurrently and looked up with bulk requests,
+// osmium.json is written once.
+func TrackProjects() error {
+	osmiumConf, err := config.ReadConfig()
 	if err != nil {
-		return modrinthVersion{}, fmt.Errorf("failed to fetch versions: %w", err)
+		return fmt.Errorf("failed to read osmium.json: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return modrinthVersion{}, fmt.Errorf("failed to fetch version by hash: %s", resp.Status)
+	// Step 1: Hash every jar osmium.json doesn't know yet, whatever source it came from
+	tracked := make(map[string]bool)
+	for _, projects := range []map[string]config.Project{osmiumConf.Mods, osmiumConf.Plugins} {
+		for _, project := range projects {
+			tracked[project.FileName] = true
+		}
 	}
 
-	var project modrinthVersion
-	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
-		return modrinthVersion{}, fmt.Errorf("failed to decode response: %w", err)
+	var jars []trackedJar
+	for _, folder := range []string{"plugins", "mods"} {
+		for _, path := range listJars(folder) {
+			if tracked[filepath.Base(path)] {
+				continue
+			}
+			jars = append(jars, trackedJar{folder: folder, path: path})
+		}
 	}
 
-	return project, nil
-}
+	if err := forEachOrdered(len(jars), func(i int, out io.Writer) error {
+		checksum, err := calculateSHA1(jars[i].path)
+		if err != nil {
+			return fmt.Errorf("failed to calculate checksum for %s: %w", jars[i].path, err)
+		}
+		jars[i].sha1 = checksum
+		return nil
+	}); err != nil {
+		fmt.Println(err)
+	}
 
-// processDirectory reads a directory and calculates SHA1 for each file
-func processDirectory(folder string) error {
-	if _, err := os.Stat(folder); os.IsNotExist(err) {
+	// Step 2: Look up all files, then all of their projects
+	var hashes []string
+	for _, jar := range jars {
+		if jar.sha1 != "" {
+			hashes = append(hashes, jar.sha1)
+		}
+	}
+	if len(hashes) == 0 {
 		return nil
 	}
 
-	entries, err := os.ReadDir(folder)
+	versions, err := getVersionsByHash(hashes)
 	if err != nil {
-		return fmt.Errorf("failed to read directory %s: %w", folder, err)
+		return err
 	}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue // skip subdirectories
+	var ids []string
+	seen := make(map[string]bool)
+	for _, version := range versions {
+		if !seen[version.ProjectID] {
+			seen[version.ProjectID] = true
+			ids = append(ids, version.ProjectID)
 		}
+	}
 
-		filePath := filepath.Join(folder, entry.Name())
+	projects, err := getProjectsByID(ids)
+	if err != nil {
+		return err
+	}
 
-		// filter .jar files
-		if !strings.HasSuffix(strings.ToLower(entry.Name()), ".jar") {
-			continue
+	// Step 3: Update osmium.json and the lock
+	var locked []lockedVersion
+	track := func(jar trackedJar, slug string, version projectVersion) {
+		if isProjectInstalled(slug, jar.folder, osmiumConf) {
+			fmt.Println(slug, "already in osmium.json")
+			return
+		}
+		if err := setConfigProject(slug, jar.folder, version, osmiumConf); err != nil {
+			fmt.Println(slug+":", err)
+			return
 		}
+		locked = append(locked, lockedVersion{folder: jar.folder, slug: slug, version: version})
+		fmt.Printf("Tracked %s (%s)\n", slug, version.Source)
+	}
 
-		checksum, err := calculateSHA1(filePath)
-		if err != nil {
-			fmt.Printf("failed to calculate checksum for %s: %v\n", filePath, err)
+	var unknown []trackedJar
+	for _, jar := range jars {
+		if jar.sha1 == "" {
 			continue
 		}
 
-		project, err := getProjectByHash(checksum)
-		if err != nil {
-			fmt.Printf("failed to fetch project metadata for %s: %v\n", filePath, err)
+		version, ok := versions[jar.sha1]
+		if !ok {
+			unknown = append(unknown, jar)
 			continue
 		}
-
-		// 1. Get project info
-		info, err := getProjectInfo(project.ProjectID)
-		if err != nil {
-			fmt.Println(err)
+		info, ok := projects[version.ProjectID]
+		if !ok {
+			fmt.Printf("failed to fetch project info for %s\n", jar.path)
 			continue
 		}
 
-		// 2. Check if already installed
-		osmiumConf, err := config.ReadConfig()
-		if err != nil {
-			return fmt.Errorf("failed to read osmium.json: %w", err)
+		if info.clientOnly() {
+			fmt.Printf("⚠ %s is client-side only and may crash the server\n", info.Slug)
 		}
+		preferFile(&version, jar.sha1)
+		track(jar, info.Slug, version.projectVersion())
+	}
 
-		if isProjectInstalled(info.Slug, folder, osmiumConf) {
-			fmt.Println(info.Slug, "already in osmium.json")
-			continue
+	// Step 4: Match what Modrinth doesn't know against CurseForge's fingerprints, when there's an API key
+	sources := "Modrinth"
+	matches := make(map[string]curseforgeMatch)
+	if len(unknown) > 0 && curseforgeAPIKey() != "" {
+		sources = "Modrinth or CurseForge"
+		if matches, err = matchCurseForgeFiles(unknown); err != nil {
+			fmt.Println(err)
 		}
-
-		// 3. Update config
-		if len(project.Files) == 0 {
-			fmt.Println("no files found in the latest version for", info.Slug)
+	}
+	for _, jar := range unknown {
+		match, ok := matches[jar.path]
+		if !ok {
+			fmt.Printf("failed to fetch project metadata for %s: not found on %s\n", jar.path, sources)
 			continue
 		}
-
-		if err := updateConfigWithProject(info.Slug, folder, project, osmiumConf); err != nil {
-			return fmt.Errorf("failed to update osmium.json: %w", err)
-		}
-
-		// // 6. Install dependencies
-		// if err := installDependencies(project.Dependencies, folder); err != nil {
-		// 	return err
-		// }
-
-		fmt.Println("Tracked", info.Slug)
+		track(jar, match.slug, match.version)
 	}
 
-	return nil
-}
-
-// trackProjects reads the plugins and mods directories and calculates SHA
-func TrackProjects() error {
-	// Process plugins directory
-	if err := processDirectory("plugins"); err != nil {
-		return err
+	if len(locked) == 0 {
+		return nil
 	}
-
-	// Process mods directory
-	if err := processDirectory("mods"); err != nil {
-		return err
+	if err := config.WriteConfig(osmiumConf); err != nil {
+		return fmt.Errorf("failed to update osmium.json: %w", err)
 	}
-
-	return nil
+	return recordLock(osmiumConf, locked...)
 }
 
+// UpdateProject updates a single project, rolling back automatically if it fails halfway
 func UpdateProject(projectID string, folder string) error {
+	return withRollbackPoint("update", func() error {
+		return updateProject(projectID, folder)
+	})
+}
+
+func updateProject(projectID string, folder string) error {
 	// 1. Check if already installed
 	osmiumConf, err := config.ReadConfig()
 	if err != nil {
@@ -487,90 +568,212 @@ func UpdateProject(projectID string, folder string) error {
 		return fmt.Errorf("%s is not installed", projectID)
 	}
 
-	// 3. Get compatible versions
-	versions, err := getProjectVersions(projectID, osmiumConf)
+	// 3. Get compatible versions from the source the project came from
+	source, err := sourceOf(projectID, folder, osmiumConf)
 	if err != nil {
 		return err
 	}
+	versions, err := source.versions(projectID, osmiumConf)
+	if errors.Is(err, errNoUpdates) {
+		fmt.Printf("%s %v, skipping\n", projectID, err)
+		return nil
+	} else if err != nil {
+		return err
+	}
 
 	// 4. Download the latest version
 	latestVersion := versions[0]
-	if len(latestVersion.Files) == 0 {
-		return fmt.Errorf("no files found in the latest version")
-	}
+	currentProject, _ := trackedProject(projectID, folder, osmiumConf)
 
-	fileInfo := latestVersion.Files[0]
-
-	var currentProject config.Project
-	switch folder {
-	case "mods":
-		currentProject = osmiumConf.Mods[projectID]
-	case "plugins":
-		currentProject = osmiumConf.Plugins[projectID]
-		// optional folders don't get tracked in config
-	}
-
-	if currentProject.SHA1 == fileInfo.Hashes.SHA1 {
+	if isLatestVersion(currentProject, latestVersion) {
 		fmt.Println(projectID, "is the latest version")
 		return nil
 	}
 
-	fmt.Printf("Updating %s...\n\n", fileInfo.Filename)
+	resolver := newDependencyResolver(osmiumConf)
+	resolver.addRoot(projectID, folder, latestVersion)
+	if err := resolver.check(); err != nil {
+		return err
+	}
 
-	os.Remove(filepath.Join(folder, currentProject.FileName))
+	fmt.Printf("Updating %s...\n\n", latestVersion.FileName)
 
-	if err := downloadFile(fileInfo.URL, folder, fileInfo.Filename); err != nil {
+	// The old jar is only removed once the new one is verified and in place
+	if err := downloadVersion(&latestVersion, folder); err != nil {
 		return err
 	}
+	if currentProject.FileName != latestVersion.FileName {
+		os.Remove(filepath.Join(folder, currentProject.FileName))
+	}
 
-	// 5. Update config
+	// 5. Update config and install new dependencies
 	if err := updateConfigWithProject(projectID, folder, latestVersion, osmiumConf); err != nil {
 		return fmt.Errorf("failed to update osmium.json: %w", err)
 	}
+	return resolver.record()
+}
 
-	// 6. Install dependencies
-	if err := installDependencies(latestVersion.Dependencies, folder); err != nil {
-		return err
-	}
+// projectUpdate is a tracked project with a newer compatible version
+type projectUpdate struct {
+	folder  string
+	slug    string
+	current config.Project
+	latest  projectVersion
+}
 
-	return nil
+// sortedSlugs returns the keys of a project map in order
+func sortedSlugs(projects map[string]config.Project) []string {
+	slugs := make([]string, 0, len(projects))
+	for slug := range projects {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+	return slugs
 }
 
+// UpdateAllProjects updates every tracked project of a folder ("mods", "plugins" or "all").
+// The newest Modrinth versions are looked up in one bulk request, other sources project by project,
+// and the updates are downloaded concurrently.
 func UpdateAllProjects(folder string) error {
 	osmiumConf, err := config.ReadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to read osmium.json: %w", err)
 	}
 
+	var folders []string
 	switch folder {
-	case "mods":
-		for mod := range osmiumConf.Mods {
-			if err := UpdateProject(mod, "mods"); err != nil {
-				fmt.Println(err)
-			}
+	case "mods", "plugins":
+		folders = []string{folder}
+	case "all":
+		folders = []string{"mods", "plugins"}
+	default:
+		return fmt.Errorf("Invalid case")
+	}
+
+	// One point for the whole batch, projects that fail are reported and skipped
+	if _, err := CreateRollbackPoint("update"); err != nil {
+		return fmt.Errorf("failed to create rollback point: %w", err)
+	}
+
+	// Step 1: Ask for the newest compatible version of every tracked file, Modrinth ones at once
+	var candidates []projectUpdate
+	var others []projectUpdate
+	var hashes []string
+	for _, folder := range folders {
+		projects := osmiumConf.Mods
+		if folder == "plugins" {
+			projects = osmiumConf.Plugins
 		}
-	case "plugins":
-		for plugin := range osmiumConf.Plugins {
-			if err := UpdateProject(plugin, "plugins"); err != nil {
-				fmt.Println(err)
+		for _, slug := range sortedSlugs(projects) {
+			project := projects[slug]
+			if project.SHA1 == "" {
+				fmt.Println(slug, "has no SHA1 in osmium.json, skipping")
+				continue
 			}
-		}
-	case "all":
-		for mod := range osmiumConf.Mods {
-			if err := UpdateProject(mod, "mods"); err != nil {
-				fmt.Println(err)
+			if sourceName(project) != SourceModrinth {
+				others = append(others, projectUpdate{folder: folder, slug: slug, current: project})
+				continue
 			}
+			candidates = append(candidates, projectUpdate{folder: folder, slug: slug, current: project})
+			hashes = append(hashes, strings.ToLower(project.SHA1))
 		}
-		for plugin := range osmiumConf.Plugins {
-			if err := UpdateProject(plugin, "plugins"); err != nil {
-				fmt.Println(err)
-			}
+	}
+
+	latest, err := getLatestVersionsByHash(hashes, osmiumConf)
+	if err != nil {
+		return err
+	}
+
+	var updates []projectUpdate
+	for _, candidate := range candidates {
+		version, ok := latest[strings.ToLower(candidate.current.SHA1)]
+		if !ok || len(version.Files) == 0 {
+			fmt.Printf("%s: %s for %s %s\n", candidate.slug, ErrIncompatible, osmiumConf.Loader, osmiumConf.Version)
+			continue
 		}
-	default:
-		return fmt.Errorf("Invalid case")
+		if strings.EqualFold(version.Files[0].Hashes.SHA1, candidate.current.SHA1) {
+			fmt.Println(candidate.slug, "is the latest version")
+			continue
+		}
+		candidate.latest = version.projectVersion()
+		updates = append(updates, candidate)
 	}
 
-	return nil
+	for _, candidate := range others {
+		source, err := getSource(candidate.current.Source)
+		if err != nil {
+			fmt.Printf("%s: %v\n", candidate.slug, err)
+			continue
+		}
+		versions, err := source.versions(candidate.slug, osmiumConf)
+		if errors.Is(err, errNoUpdates) {
+			fmt.Printf("%s %v, skipping\n", candidate.slug, err)
+			continue
+		} else if err != nil {
+			fmt.Printf("%s: %v\n", candidate.slug, err)
+			continue
+		}
+		if isLatestVersion(candidate.current, versions[0]) {
+			fmt.Println(candidate.slug, "is the latest version")
+			continue
+		}
+		candidate.latest = versions[0]
+		updates = append(updates, candidate)
+	}
+
+	// Step 2: Resolve the dependencies of the new versions, conflicts stop the update before anything is downloaded
+	resolver := newDependencyResolver(osmiumConf)
+	for _, update := range updates {
+		resolver.addRoot(update.slug, update.folder, update.latest)
+	}
+	if err := resolver.check(); err != nil {
+		return err
+	}
+
+	// Step 3: Download the updates
+	updated := make([]bool, len(updates))
+	downloadErr := forEachOrdered(len(updates), func(i int, out io.Writer) error {
+		fmt.Fprintf(out, "Updating %s...\n\n", updates[i].latest.FileName)
+		if err := downloadVersion(&updates[i].latest, updates[i].folder); err != nil {
+			return fmt.Errorf("failed to update %s: %w", updates[i].slug, err)
+		}
+		updated[i] = true
+		return nil
+	})
+
+	// Step 4: Record the new versions and their new dependencies, then drop the replaced jars
+	var locked []lockedVersion
+	var failed []string
+	for i, update := range updates {
+		if !updated[i] {
+			failed = append(failed, update.slug)
+			continue
+		}
+		if err := setConfigProject(update.slug, update.folder, update.latest, osmiumConf); err != nil {
+			return err
+		}
+		locked = append(locked, lockedVersion{folder: update.folder, slug: update.slug, version: update.latest})
+	}
+
+	resolver.skip(failed...)
+	dependencies, depErr := resolver.install()
+	locked = append(locked, dependencies...)
+	if len(locked) > 0 || resolver.dirty {
+		if err := config.WriteConfig(osmiumConf); err != nil {
+			return fmt.Errorf("failed to update osmium.json: %w", err)
+		}
+		if err := recordLock(osmiumConf, locked...); err != nil {
+			return err
+		}
+	}
+
+	for i, update := range updates {
+		if updated[i] && update.current.FileName != update.latest.FileName {
+			os.Remove(filepath.Join(update.folder, update.current.FileName))
+		}
+	}
+
+	return errors.Join(downloadErr, depErr)
 }
 
 func InstallProjectByID(projectID string, folder string) error {
@@ -585,65 +788,187 @@ func InstallProjectByID(projectID string, folder string) error {
 		return nil
 	}
 
-	// 3. Get compatible versions
-	versions, err := getProjectVersions(projectID, osmiumConf)
+	// 3. Get compatible versions, offline the pinned version comes from the download cache
+	if util.Offline {
+		return installPinnedProject(projectID, folder, osmiumConf, os.Stdout)
+	}
+
+	source, err := sourceOf(projectID, folder, osmiumConf)
 	if err != nil {
 		return err
 	}
 
-	// 4. Download the latest version
-	latestVersion := versions[0]
-	if len(latestVersion.Files) == 0 {
-		return fmt.Errorf("no files found in the latest version")
+	versions, err := source.versions(projectID, osmiumConf)
+	if err != nil {
+		if pinnedErr := installPinnedProject(projectID, folder, osmiumConf, os.Stdout); pinnedErr == nil {
+			fmt.Printf("⚠ Couldn't resolve %s on %s (%v), installed the pinned version\n", projectID, source.name(), err)
+			return nil
+		}
+		return err
 	}
 
-	fileInfo := latestVersion.Files[0]
-	fmt.Printf("Downloading %s...\n\n", fileInfo.Filename)
-
-	if err := downloadFile(fileInfo.URL, folder, fileInfo.Filename); err != nil {
+	// 4. Download the latest version once its dependencies are resolved
+	latestVersion := versions[0]
+	resolver := newDependencyResolver(osmiumConf)
+	resolver.addRoot(projectID, folder, latestVersion)
+	if err := resolver.check(); err != nil {
 		return err
 	}
 
-	// 5. Install dependencies
-	if err := installDependencies(latestVersion.Dependencies, folder); err != nil {
+	fmt.Printf("Downloading %s...\n\n", latestVersion.FileName)
+	if err := downloadVersion(&latestVersion, folder); err != nil {
 		return err
 	}
 
-	return nil
+	// 5. Install dependencies
+	return resolver.record()
+}
+
+// projectInstall is a tracked project that isn't installed yet
+type projectInstall struct {
+	folder  string
+	slug    string
+	project config.Project
 }
 
+// InstallProjectsFromConfig installs every tracked project that's missing. The newest compatible
+// Modrinth versions are looked up in one bulk request and downloaded concurrently. Projects from other
+// sources, and every project offline or when Modrinth can't be reached, get the version pinned in osmium.json.
 func InstallProjectsFromConfig() error {
 	osmiumConf, err := config.ReadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to read osmium.json: %w", err)
 	}
 
-	for mod := range osmiumConf.Mods {
-		if err := InstallProjectByID(mod, "mods"); err != nil {
-			fmt.Println(err)
+	var pending []projectInstall
+	var unpinned []projectInstall // no SHA1 to look up, resolved one by one
+	for _, folder := range []string{"mods", "plugins"} {
+		projects := osmiumConf.Mods
+		if folder == "plugins" {
+			projects = osmiumConf.Plugins
+		}
+		for _, slug := range sortedSlugs(projects) {
+			if isProjectInstalled(slug, folder, osmiumConf) {
+				fmt.Printf("%s already installed\n\n", slug)
+				continue
+			}
+
+			install := projectInstall{folder: folder, slug: slug, project: projects[slug]}
+			if install.project.SHA1 == "" {
+				unpinned = append(unpinned, install)
+			} else {
+				pending = append(pending, install)
+			}
 		}
 	}
-	for plugin := range osmiumConf.Plugins {
-		if err := InstallProjectByID(plugin, "plugins"); err != nil {
-			fmt.Println(err)
+
+	// Step 1: Resolve the newest compatible versions
+	latest := make(map[string]modrinthVersion)
+	if !util.Offline && len(pending) > 0 {
+		var hashes []string
+		for _, install := range pending {
+			if sourceName(install.project) == SourceModrinth {
+				hashes = append(hashes, strings.ToLower(install.project.SHA1))
+			}
+		}
+		if latest, err = getLatestVersionsByHash(hashes, osmiumConf); err != nil {
+			fmt.Printf("⚠ %v, installing the pinned versions\n\n", err)
+			latest = make(map[string]modrinthVersion)
 		}
 	}
 
-	return nil
+	// Step 2: Resolve the dependencies of the new versions before downloading anything,
+	// the pinned versions worked together when they were recorded
+	resolver := newDependencyResolver(osmiumConf)
+	for _, install := range pending {
+		found, ok := latest[strings.ToLower(install.project.SHA1)]
+		if ok && len(found.Files) > 0 && sourceName(install.project) == SourceModrinth {
+			resolver.addRoot(install.slug, install.folder, found.projectVersion())
+		}
+	}
+	var conflicts *DependencyConflictError
+	if err := resolver.check(); errors.As(err, &conflicts) {
+		fmt.Printf("⚠ The newest versions conflict, installing the pinned versions:\n  %s\n\n", strings.Join(conflicts.Conflicts, "\n  "))
+		latest = make(map[string]modrinthVersion)
+		resolver = newDependencyResolver(osmiumConf)
+	} else if err != nil {
+		return err
+	}
+
+	// Step 3: Download, falling back to the pinned version when there's no newer one
+	installed := make([]*projectVersion, len(pending))
+	manual := make([]*ManualDownloadError, len(pending))
+	downloadErr := forEachOrdered(len(pending), func(i int, out io.Writer) error {
+		install := pending[i]
+		found, ok := latest[strings.ToLower(install.project.SHA1)]
+		if !ok || len(found.Files) == 0 || sourceName(install.project) != SourceModrinth {
+			err := installPinnedProject(install.slug, install.folder, osmiumConf, out)
+			if errors.As(err, &manual[i]) {
+				return nil // listed below
+			}
+			return err
+		}
+
+		version := found.projectVersion()
+		fmt.Fprintf(out, "Downloading %s...\n\n", version.FileName)
+		if err := downloadVersion(&version, install.folder); err != nil {
+			return fmt.Errorf("failed to install %s: %w", install.slug, err)
+		}
+		installed[i] = &version
+		return nil
+	})
+
+	// Step 4: Dependencies, then unpinned projects one at a time since they write osmium.json
+	var failed []string
+	for i, install := range pending {
+		if installed[i] == nil {
+			failed = append(failed, install.slug)
+		}
+	}
+	resolver.skip(failed...)
+	errs := []error{downloadErr, resolver.record()}
+	for _, install := range unpinned {
+		if err := InstallProjectByID(install.slug, install.folder); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Step 5: List the files that have to be downloaded by hand
+	var missing []*ManualDownloadError
+	for _, m := range manual {
+		if m != nil {
+			missing = append(missing, m)
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Println("⚠ These files don't allow third-party downloads, download them manually:")
+		for _, m := range missing {
+			fmt.Printf("  %s: %s from %s into %s/\n", m.Project, m.FileName, m.URL, m.Folder)
+		}
+		fmt.Println()
+		errs = append(errs, fmt.Errorf("%d projects have to be downloaded manually", len(missing)))
+	}
+
+	return errors.Join(errs...)
 }
 
 // migrateProject attempts to migrate a single project to new loader/version
 // Returns true if migrated successfully, false if incompatible (needs backup)
 func migrateProject(slug string, folder string, newConf *config.OsmiumConfig) (bool, error) {
-	// Get compatible versions for the new loader/version
-	versions, err := getProjectVersions(slug, newConf)
+	source, err := sourceOf(slug, folder, newConf)
 	if err != nil {
-		// Project not compatible with new loader/version
 		return false, err
 	}
 
-	if len(versions) == 0 {
-		return false, fmt.Errorf("no compatible versions found")
+	// Get compatible versions for the new loader/version, projects added from a URL or a file stay as they are
+	versions, err := source.versions(slug, newConf)
+	if errors.Is(err, errNoUpdates) {
+		fmt.Printf("⚠ %s %v, keeping it as is\n", slug, err)
+		return true, nil
+	}
+	if err != nil {
+		// Project not compatible with new loader/version
+		return false, err
 	}
 
 	// Get current project to delete old file
@@ -664,19 +989,14 @@ func migrateProject(slug string, folder string, newConf *config.OsmiumConfig) (b
 
 	// Download new version
 	latestVersion := versions[0]
-	if len(latestVersion.Files) == 0 {
-		return false, fmt.Errorf("no files found in the latest version")
-	}
-
-	fileInfo := latestVersion.Files[0]
-	fmt.Printf("Migrating %s to %s...\n", slug, fileInfo.Filename)
+	fmt.Printf("Migrating %s to %s...\n", slug, latestVersion.FileName)
 
-	if err := downloadFile(fileInfo.URL, folder, fileInfo.Filename); err != nil {
+	if err := downloadVersion(&latestVersion, folder); err != nil {
 		return false, err
 	}
 
 	// Remove old file if it exists and is different
-	if exists && oldProject.FileName != fileInfo.Filename {
+	if exists && oldProject.FileName != latestVersion.FileName {
 		oldPath := filepath.Join(folder, oldProject.FileName)
 		if err := os.Remove(oldPath); err != nil && !os.IsNotExist(err) {
 			fmt.Printf("Warning: failed to remove old file %s: %v\n", oldPath, err)
@@ -691,7 +1011,7 @@ func migrateProject(slug string, folder string, newConf *config.OsmiumConfig) (b
 	}
 
 	// Install dependencies
-	if err := installDependencies(latestVersion.Dependencies, folder); err != nil {
+	if err := installDependencies(slug, folder, latestVersion, newConf); err != nil {
 		fmt.Printf("Warning: failed to install dependencies for %s: %v\n", slug, err)
 	}
 
@@ -750,7 +1070,15 @@ func processProjectsForMigration(folder string, projects map[string]config.Proje
 	return nil
 }
 
+// MigrateServer moves the server to a new loader/version.
+// A rollback point is taken first and restored automatically if the migration fails.
 func MigrateServer(loader string, version string) error {
+	return withRollbackPoint("migrate", func() error {
+		return migrateServer(loader, version)
+	})
+}
+
+func migrateServer(loader string, version string) error {
 	fmt.Printf("\n=== Starting server migration to %s %s ===\n\n", loader, version)
 
 	// Read current config
@@ -816,6 +1144,9 @@ func MigrateServer(loader string, version string) error {
 	if err := config.WriteConfig(newConf); err != nil {
 		return fmt.Errorf("failed to update osmium.json: %w", err)
 	}
+	if err := recordLock(newConf); err != nil {
+		return err
+	}
 	fmt.Println("✓ Config updated")
 
 	// Step 4: Download and install new server.jar (only if loader or version changed)
@@ -833,6 +1164,7 @@ func MigrateServer(loader string, version string) error {
 	fmt.Printf("\n=== Migration completed! ===\n")
 	fmt.Printf("Server migrated from %s %s to %s %s\n", oldConf.Loader, oldConf.Version, loader, version)
 	fmt.Println("\nNote: World folders, server.properties, and other config files remain intact.")
+	fmt.Println("Use 'osmium backup create' to snapshot your worlds before starting the server.")
 	if _, err := os.Stat("migration_backup"); err == nil {
 		fmt.Println("Incompatible mods/plugins have been moved to 'migration_backup' folder.")
 	}
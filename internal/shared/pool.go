@@ -0,0 +1,50 @@
+package shared
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// maxWorkers bounds how many downloads run at the same time
+const maxWorkers = 8
+
+// forEachOrdered calls fn for 0..n-1 on up to maxWorkers goroutines. Each call writes its output to
+// its own buffer and the buffers are printed in index order, so the log reads like a sequential run.
+// The errors of all calls are joined in index order.
+func forEachOrdered(n int, fn func(i int, out io.Writer) error) error {
+	buffers := make([]bytes.Buffer, n)
+	errs := make([]error, n)
+	done := make([]chan struct{}, n)
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(maxWorkers, n) {
+		wg.Go(func() {
+			for i := range jobs {
+				errs[i] = fn(i, &buffers[i])
+				close(done[i])
+			}
+		})
+	}
+
+	go func() {
+		for i := range n {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	for i := range n {
+		<-done[i]
+		os.Stdout.Write(buffers[i].Bytes())
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -83,3 +83,12 @@ func IsPIDRunning(pid int) bool {
 		return true
 	}
 }
+
+// IsServerRunning reports whether the lock file points at a live process.
+func IsServerRunning() bool {
+	pid, err := ReadLockPID()
+	if err != nil {
+		return false
+	}
+	return IsPIDRunning(pid)
+}
@@ -0,0 +1,303 @@
+package shared
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+	"github.com/limelamp/osmium/internal/util"
+)
+
+/*
+	Dependencies stay behind when the projects that pulled them in are removed. An orphan is one of:
+
+		a tracked project whose dependency_of projects are all gone
+		a jar in optional_mods / optional_plugins that no tracked project lists as a dependency
+		a Modrinth library nothing tracked requires, only when asked for since it's a guess:
+		installs that predate dependency_of have libraries players or other plugins rely on
+
+	What the remaining projects require comes from Modrinth by hash and from the lock, whatever
+	their source. The last two need Modrinth, offline only the recorded dependencies are checked.
+*/
+
+// Orphan is a dependency no remaining project needs
+type Orphan struct {
+	Slug     string // empty for jars in the optional folders, which aren't tracked
+	Folder   string
+	FileName string
+	Reason   string
+}
+
+// Path returns the file of the orphan
+func (o Orphan) Path() string {
+	return filepath.Join(o.Folder, o.FileName)
+}
+
+// Name returns the slug of the orphan, or its file name when it isn't tracked
+func (o Orphan) Name() string {
+	if o.Slug != "" {
+		return o.Slug
+	}
+	return o.FileName
+}
+
+// trackedEntry is a project in osmium.json
+type trackedEntry struct {
+	slug    string
+	folder  string
+	project config.Project
+}
+
+// trackedEntries returns every project in osmium.json, mods first, in order
+func trackedEntries(conf *config.OsmiumConfig) []trackedEntry {
+	var entries []trackedEntry
+	for _, folder := range []string{"mods", "plugins"} {
+		projects := conf.Mods
+		if folder == "plugins" {
+			projects = conf.Plugins
+		}
+		for _, slug := range sortedSlugs(projects) {
+			entries = append(entries, trackedEntry{slug: slug, folder: folder, project: projects[slug]})
+		}
+	}
+	return entries
+}
+
+// FindOrphans returns the dependencies of the server in the current directory that no remaining project needs.
+// Libraries nothing records as a dependent are only included when libraries is set.
+func FindOrphans(libraries bool) ([]Orphan, error) {
+	osmiumConf, err := config.ReadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read osmium.json: %w", err)
+	}
+	entries := trackedEntries(osmiumConf)
+
+	// Step 1: Projects pulled in as dependencies whose dependents are gone, repeated for their own dependencies
+	orphaned := make(map[string]bool)
+	remaining := func(slug string) bool {
+		_, mod := osmiumConf.Mods[slug]
+		_, plugin := osmiumConf.Plugins[slug]
+		return (mod || plugin) && !orphaned[slug]
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, entry := range entries {
+			if orphaned[entry.slug] || len(entry.project.DependencyOf) == 0 {
+				continue
+			}
+			if !slices.ContainsFunc(entry.project.DependencyOf, remaining) {
+				orphaned[entry.slug] = true
+				changed = true
+			}
+		}
+	}
+
+	var orphans []Orphan
+	for _, entry := range entries {
+		if orphaned[entry.slug] {
+			orphans = append(orphans, Orphan{
+				Slug:     entry.slug,
+				Folder:   entry.folder,
+				FileName: entry.project.FileName,
+				Reason:   "was a dependency of " + strings.Join(entry.project.DependencyOf, ", "),
+			})
+		}
+	}
+
+	if util.Offline {
+		return orphans, nil
+	}
+
+	// Step 2: Ask Modrinth and the lock what every remaining project depends on
+	found, err := findUnneededDependencies(entries, orphaned, libraries)
+	if err != nil {
+		fmt.Printf("⚠ Only checked the recorded dependencies: %v\n", err)
+		return orphans, nil
+	}
+	return append(orphans, found...), nil
+}
+
+// findUnneededDependencies finds the optional jars, and the Modrinth libraries when asked for, nothing needs
+func findUnneededDependencies(entries []trackedEntry, orphaned map[string]bool, libraries bool) ([]Orphan, error) {
+	var optionalJars []string
+	for _, folder := range []string{"optional_mods", "optional_plugins"} {
+		optionalJars = append(optionalJars, listJars(folder)...)
+	}
+
+	// Every remaining project is looked up, whatever its source, Modrinth knows many files from elsewhere
+	hashes := make(map[string]string) // path or slug to SHA1
+	var lookups []string
+	for _, entry := range entries {
+		if orphaned[entry.slug] {
+			continue
+		}
+		checksum := strings.ToLower(entry.project.SHA1)
+		if checksum == "" {
+			sum, err := calculateSHA1(filepath.Join(entry.folder, entry.project.FileName))
+			if err != nil {
+				continue
+			}
+			checksum = sum
+		}
+		hashes[entry.slug] = checksum
+		lookups = append(lookups, checksum)
+	}
+	for _, path := range optionalJars {
+		checksum, err := calculateSHA1(path)
+		if err != nil {
+			continue
+		}
+		hashes[path] = checksum
+		lookups = append(lookups, checksum)
+	}
+	if len(lookups) == 0 {
+		return nil, nil
+	}
+
+	versions, err := getVersionsByHash(lookups)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := config.ReadLock()
+	if os.IsNotExist(err) {
+		lock = &config.LockFile{}
+	} else if err != nil {
+		return nil, err
+	}
+	lockedEntry := func(entry trackedEntry) (config.LockedProject, bool) {
+		locks := lock.Mods
+		if entry.folder == "plugins" {
+			locks = lock.Plugins
+		}
+		locked, ok := locks[entry.slug]
+		return locked, ok
+	}
+
+	// Everything the remaining tracked projects depend on: Modrinth's dependencies of their files
+	// and the ones the lock recorded from their own source, which are matched by the locked project ID
+	needed := make(map[string]bool)
+	lockedIDs := make(map[string]bool)
+	var unknown []string // remaining projects neither Modrinth nor the lock knows the dependencies of
+	for _, entry := range entries {
+		if orphaned[entry.slug] {
+			continue
+		}
+		version, known := versions[hashes[entry.slug]]
+		for _, dep := range version.Dependencies {
+			needed[dep.ProjectID] = true
+		}
+		locked, ok := lockedEntry(entry)
+		for _, dep := range locked.Dependencies {
+			lockedIDs[dep] = true
+		}
+		if !known && !ok {
+			unknown = append(unknown, entry.slug)
+		}
+	}
+	isNeeded := func(entry trackedEntry, projectID string) bool {
+		locked, _ := lockedEntry(entry)
+		return needed[projectID] || (locked.ProjectID != "" && lockedIDs[locked.ProjectID])
+	}
+
+	// Anything could be what the unknown projects need, guessing would delete it from under them
+	if len(unknown) > 0 {
+		fmt.Printf("⚠ Not checking for unused optional jars or libraries, osmium doesn't know the dependencies of %s\n", strings.Join(unknown, ", "))
+		return nil, nil
+	}
+
+	// Optional jars are needed when a needed project depends on them, their dependencies are installed next to them
+	neededJars := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, path := range optionalJars {
+			version, ok := versions[hashes[path]]
+			if !ok || neededJars[path] || !needed[version.ProjectID] {
+				continue
+			}
+			neededJars[path] = true
+			changed = true
+			for _, dep := range version.Dependencies {
+				needed[dep.ProjectID] = true
+			}
+		}
+	}
+
+	var orphans []Orphan
+	for _, path := range optionalJars {
+		if _, ok := versions[hashes[path]]; !ok || neededJars[path] {
+			continue // jars Modrinth doesn't know are left alone
+		}
+		orphans = append(orphans, Orphan{
+			Folder:   filepath.Dir(path),
+			FileName: filepath.Base(path),
+			Reason:   "optional dependency no tracked project uses",
+		})
+	}
+	if !libraries {
+		return orphans, nil
+	}
+
+	// Libraries nothing requires, for projects added before dependency_of was recorded
+	candidates := make(map[string]trackedEntry)
+	var ids []string
+	for _, entry := range entries {
+		version, ok := versions[hashes[entry.slug]]
+		if !ok || orphaned[entry.slug] || len(entry.project.DependencyOf) > 0 || isNeeded(entry, version.ProjectID) {
+			continue
+		}
+		candidates[version.ProjectID] = entry
+		ids = append(ids, version.ProjectID)
+	}
+	if len(ids) == 0 {
+		return orphans, nil
+	}
+
+	projects, err := getProjectsByID(ids)
+	if err != nil {
+		return orphans, err
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if info, ok := projects[id]; ok && slices.Contains(info.Categories, "library") {
+			entry := candidates[id]
+			orphans = append(orphans, Orphan{
+				Slug:     entry.slug,
+				Folder:   entry.folder,
+				FileName: entry.project.FileName,
+				Reason:   "library no tracked project requires",
+			})
+		}
+	}
+	return orphans, nil
+}
+
+// RemoveOrphans deletes the orphans and drops the tracked ones from osmium.json and the lock
+func RemoveOrphans(orphans []Orphan) error {
+	osmiumConf, err := config.ReadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read osmium.json: %w", err)
+	}
+
+	for _, orphan := range orphans {
+		if err := os.Remove(orphan.Path()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", orphan.Path(), err)
+		}
+		switch orphan.Folder {
+		case "mods":
+			delete(osmiumConf.Mods, orphan.Slug)
+		case "plugins":
+			delete(osmiumConf.Plugins, orphan.Slug)
+		}
+		fmt.Printf("Removed %s\n", orphan.Name())
+	}
+
+	if err := config.WriteConfig(osmiumConf); err != nil {
+		return fmt.Errorf("failed to update osmium.json: %w", err)
+	}
+	return recordLock(osmiumConf)
+}
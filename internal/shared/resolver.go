@@ -0,0 +1,473 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+	"github.com/limelamp/osmium/internal/util"
+)
+
+/*
+	The dependency resolver works out everything new or updated projects pull in before anything
+	is downloaded. It walks their dependencies depth first, next to the projects osmium.json tracks:
+
+		required     — installed in the same folder
+		optional     — installed in optional_mods / optional_plugins
+		embedded     — bundled inside the project's jar, nothing to install
+		incompatible — a conflict when that project is tracked or about to be installed
+
+	A dependency that pins a version (version_id on Modrinth) gets exactly that version. Pins that
+	disagree with each other, or with the tracked version of a project, are conflicts too.
+	Cycles are reported but not fatal, every project is installed once.
+	Projects pulled in as dependencies record the projects that need them in osmium.json ("dependency_of").
+*/
+
+// DependencyConflictError lists the conflicts found while resolving dependencies,
+// nothing is downloaded when there are any
+type DependencyConflictError struct {
+	Conflicts []string
+}
+
+func (e *DependencyConflictError) Error() string {
+	return fmt.Sprintf("%d dependency conflicts, nothing was installed:\n  %s", len(e.Conflicts), strings.Join(e.Conflicts, "\n  "))
+}
+
+// depNode is a project in the dependency graph
+type depNode struct {
+	slug       string
+	folder     string
+	versionID  string          // empty when unknown
+	version    *projectVersion // the version to install, nil for tracked projects that stay as they are
+	tracked    bool            // in osmium.json
+	requiredBy []string
+	installed  bool
+
+	incompatible []dependency // projects it declares it doesn't work with
+}
+
+// depPin is a dependency on an exact version
+type depPin struct {
+	versionID string
+	by        string
+}
+
+// dependencyResolver builds the dependency graph of new and updated projects
+type dependencyResolver struct {
+	conf      *config.OsmiumConfig
+	nodes     map[string]*depNode // by slug
+	ids       map[string]string   // "source:project ID" to slug
+	pins      map[string][]depPin // by "source:project ID"
+	planned   []*depNode          // new dependencies, in the order they were found
+	conflicts []string
+	warnings  []string
+	dirty     bool // dependency_of of a tracked project changed
+}
+
+// dependencyKey identifies a project across sources
+func dependencyKey(source, projectID string) string {
+	if source == "" {
+		source = SourceModrinth
+	}
+	return source + ":" + projectID
+}
+
+// newDependencyResolver returns a resolver that knows the projects tracked in conf,
+// and their project IDs from the lock
+func newDependencyResolver(conf *config.OsmiumConfig) *dependencyResolver {
+	r := &dependencyResolver{
+		conf:  conf,
+		nodes: make(map[string]*depNode),
+		ids:   make(map[string]string),
+		pins:  make(map[string][]depPin),
+	}
+
+	lock, _ := config.ReadLock()
+	for _, folder := range []string{"mods", "plugins"} {
+		projects := conf.Mods
+		var locks map[string]config.LockedProject
+		if folder == "plugins" {
+			projects = conf.Plugins
+		}
+		if lock != nil {
+			locks = lock.Mods
+			if folder == "plugins" {
+				locks = lock.Plugins
+			}
+		}
+
+		for slug, project := range projects {
+			node := &depNode{slug: slug, folder: folder, tracked: true}
+			if locked, ok := locks[slug]; ok && strings.EqualFold(locked.SHA1, project.SHA1) {
+				node.versionID = locked.VersionID
+				r.ids[dependencyKey(locked.Source, locked.ProjectID)] = slug
+			}
+			r.ids[dependencyKey(project.Source, slug)] = slug
+			r.nodes[slug] = node
+		}
+	}
+	return r
+}
+
+// addRoot adds a project that's being installed or updated, and resolves its dependencies
+func (r *dependencyResolver) addRoot(slug, folder string, version projectVersion) {
+	node, ok := r.nodes[slug]
+	if !ok {
+		node = &depNode{slug: slug, folder: folder}
+		r.nodes[slug] = node
+	}
+	node.version = &version
+	node.versionID = version.ID
+	r.ids[dependencyKey(version.Source, version.ProjectID)] = slug
+
+	r.visit(node, []string{slug})
+}
+
+// visit resolves the dependencies of a node, path is the chain of projects that led to it
+func (r *dependencyResolver) visit(node *depNode, path []string) {
+	for _, dep := range node.version.Dependencies {
+		switch dep.DependencyType {
+		case "embedded":
+			continue
+		case "incompatible":
+			node.incompatible = append(node.incompatible, dep)
+			continue
+		}
+
+		folder, err := getDependencyFolder(node.folder, dep.DependencyType)
+		if err != nil {
+			r.warnings = append(r.warnings, fmt.Sprintf("%s: %v", node.slug, err))
+			continue
+		}
+		if dep.externalURL != "" {
+			r.warnings = append(r.warnings, fmt.Sprintf("%s (%s) isn't on any source, download it from %s", dep.ProjectID, dep.DependencyType, dep.externalURL))
+			continue
+		}
+
+		slug, pinned, err := r.identify(dep, node.slug)
+		if errors.Is(err, ErrClientOnly) {
+			r.warnings = append(r.warnings, fmt.Sprintf("%s: skipping dependency %s: %v", node.slug, dep.ProjectID, err))
+			continue
+		}
+		if err != nil {
+			r.fail(dep.DependencyType, fmt.Sprintf("%s requires %s: %v", node.slug, dep.ProjectID, err))
+			continue
+		}
+
+		if i := slices.Index(path, slug); i >= 0 {
+			cycle := append(slices.Clone(path[i:]), slug)
+			r.warnings = append(r.warnings, "dependency cycle: "+strings.Join(cycle, " → "))
+			continue
+		}
+
+		if existing, ok := r.nodes[slug]; ok {
+			if !slices.Contains(existing.requiredBy, node.slug) {
+				existing.requiredBy = append(existing.requiredBy, node.slug)
+			}
+			// A required dependency moves out of the optional folder
+			if !existing.tracked && strings.HasPrefix(existing.folder, "optional_") && !strings.HasPrefix(folder, "optional_") {
+				existing.folder = folder
+			}
+			continue
+		}
+
+		version := pinned
+		if version == nil {
+			source, err := getSource(dep.source)
+			if err != nil {
+				r.fail(dep.DependencyType, fmt.Sprintf("%s requires %s: %v", node.slug, slug, err))
+				continue
+			}
+			versions, err := source.versions(slug, r.conf)
+			if err != nil {
+				r.fail(dep.DependencyType, fmt.Sprintf("%s requires %s: %v", node.slug, slug, err))
+				continue
+			}
+			version = &versions[0]
+		}
+
+		added := &depNode{slug: slug, folder: folder, versionID: version.ID, version: version, requiredBy: []string{node.slug}}
+		r.nodes[slug] = added
+		r.ids[dependencyKey(version.Source, version.ProjectID)] = slug
+		r.planned = append(r.planned, added)
+		r.visit(added, append(slices.Clone(path), slug))
+	}
+}
+
+// identify returns the slug of a dependency, and the version it pins if it pins one
+func (r *dependencyResolver) identify(dep dependency, by string) (string, *projectVersion, error) {
+	source, err := getSource(dep.source)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var pinned *projectVersion
+	projectID := dep.ProjectID
+	if dep.VersionID != "" && source.name() == SourceModrinth {
+		version, err := getVersion(dep.VersionID)
+		if err != nil {
+			return "", nil, err
+		}
+		converted := version.projectVersion()
+		pinned = &converted
+		projectID = version.ProjectID
+
+		key := dependencyKey(SourceModrinth, projectID)
+		r.pins[key] = append(r.pins[key], depPin{versionID: dep.VersionID, by: by})
+	}
+
+	if slug, ok := r.ids[dependencyKey(source.name(), projectID)]; ok {
+		return slug, pinned, nil
+	}
+	slug, err := resolveForServer(source, projectID)
+	if err != nil {
+		return "", nil, err
+	}
+	r.ids[dependencyKey(source.name(), projectID)] = slug
+	return slug, pinned, nil
+}
+
+// fail records a problem with a dependency, a conflict when it's required
+func (r *dependencyResolver) fail(depType, problem string) {
+	if depType == "required" {
+		r.conflicts = append(r.conflicts, problem)
+	} else {
+		r.warnings = append(r.warnings, problem+", skipping")
+	}
+}
+
+// changed reports whether a node is new or gets another version
+func (n *depNode) changed() bool {
+	return n.version != nil
+}
+
+// loadTracked reads the dependencies of the tracked Modrinth projects from one bulk request,
+// for the incompatibilities and pins they declare
+func (r *dependencyResolver) loadTracked() {
+	var hashes []string
+	bySHA1 := make(map[string]*depNode)
+	for slug, node := range r.nodes {
+		if !node.tracked || node.changed() {
+			continue
+		}
+		project, _ := trackedProject(slug, node.folder, r.conf)
+		if sourceName(project) != SourceModrinth || project.SHA1 == "" {
+			continue
+		}
+		hashes = append(hashes, strings.ToLower(project.SHA1))
+		bySHA1[strings.ToLower(project.SHA1)] = node
+	}
+	if len(hashes) == 0 || util.Offline {
+		return
+	}
+
+	versions, err := getVersionsByHash(hashes)
+	if err != nil {
+		r.warnings = append(r.warnings, fmt.Sprintf("couldn't check the dependencies of tracked projects: %v", err))
+		return
+	}
+
+	for sha1, version := range versions {
+		node, ok := bySHA1[strings.ToLower(sha1)]
+		if !ok {
+			continue
+		}
+		node.versionID = version.ID
+		r.ids[dependencyKey(SourceModrinth, version.ProjectID)] = node.slug
+
+		for _, dep := range version.Dependencies {
+			switch {
+			case dep.DependencyType == "incompatible":
+				node.incompatible = append(node.incompatible, dep)
+			case dep.VersionID != "" && dep.ProjectID != "":
+				key := dependencyKey(SourceModrinth, dep.ProjectID)
+				r.pins[key] = append(r.pins[key], depPin{versionID: dep.VersionID, by: node.slug})
+			}
+		}
+	}
+}
+
+// check finishes the graph: it looks for incompatibilities and pins that don't hold,
+// prints the warnings and returns the conflicts
+func (r *dependencyResolver) check() error {
+	hasChanges := len(r.planned) > 0
+	for _, node := range r.nodes {
+		hasChanges = hasChanges || node.changed()
+	}
+	if hasChanges {
+		r.loadTracked()
+	}
+
+	slugs := make([]string, 0, len(r.nodes))
+	for slug := range r.nodes {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	for _, slug := range slugs {
+		node := r.nodes[slug]
+		for _, dep := range node.incompatible {
+			other, ok := r.ids[dependencyKey(dep.source, dep.ProjectID)]
+			otherNode, tracked := r.nodes[other]
+			if !ok || !tracked || other == slug {
+				continue
+			}
+			// Projects that were already installed together aren't this install's problem
+			if node.changed() || otherNode.changed() {
+				r.conflicts = append(r.conflicts, fmt.Sprintf("%s is incompatible with %s", slug, other))
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(r.pins))
+	for key := range r.pins {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		node, ok := r.nodes[r.ids[key]]
+		if !ok {
+			continue
+		}
+		slug := node.slug
+		for _, pin := range r.pins[key] {
+			if node.versionID == "" || node.versionID == pin.versionID {
+				continue
+			}
+			if node.changed() || r.nodes[pin.by].changed() {
+				r.conflicts = append(r.conflicts, fmt.Sprintf("%s needs %s version %s, but %s would be at %s", pin.by, slug, pin.versionID, slug, node.versionID))
+			}
+		}
+	}
+
+	for _, warning := range r.warnings {
+		fmt.Printf("⚠ %s\n", warning)
+	}
+	if len(r.conflicts) > 0 {
+		return &DependencyConflictError{Conflicts: r.conflicts}
+	}
+	return nil
+}
+
+// skip drops the planned dependencies that only the given projects need, e.g. of updates that failed
+func (r *dependencyResolver) skip(slugs ...string) {
+	dropped := make(map[string]bool)
+	for _, slug := range slugs {
+		dropped[slug] = true
+	}
+
+	for changed := len(slugs) > 0; changed; {
+		changed = false
+		kept := r.planned[:0]
+		for _, node := range r.planned {
+			needed := slices.ContainsFunc(node.requiredBy, func(slug string) bool { return !dropped[slug] })
+			if !needed && !dropped[node.slug] {
+				dropped[node.slug] = true
+				changed = true
+				continue
+			}
+			kept = append(kept, node)
+		}
+		r.planned = kept
+	}
+}
+
+// install downloads the new dependencies concurrently and puts them into the config, recording who
+// needs them. Tracked projects that were pulled in as dependencies get their new dependents too.
+// It returns the versions to lock, the config isn't written.
+func (r *dependencyResolver) install() ([]lockedVersion, error) {
+	err := forEachOrdered(len(r.planned), func(i int, out io.Writer) error {
+		node := r.planned[i]
+		if _, err := os.Stat(filepath.Join(node.folder, node.version.FileName)); err == nil {
+			node.installed = true
+			return nil
+		}
+
+		fmt.Fprintf(out, "Installing dependency %s (required by %s) in %s\n", node.slug, strings.Join(node.requiredBy, ", "), node.folder)
+		if err := downloadVersion(node.version, node.folder); err != nil {
+			var manual *ManualDownloadError
+			if !errors.As(err, &manual) {
+				return fmt.Errorf("failed to install dependency %s: %w", node.slug, err)
+			}
+			manual.Project = node.slug
+			fmt.Fprintf(out, "⚠ %v\n", manual)
+		}
+		node.installed = true
+		return nil
+	})
+
+	var locked []lockedVersion
+	for _, node := range r.planned {
+		if !node.installed {
+			continue
+		}
+		if setErr := setConfigProject(node.slug, node.folder, *node.version, r.conf); setErr != nil {
+			err = errors.Join(err, setErr)
+			continue
+		}
+		r.addDependents(node.slug, node.folder, node.requiredBy)
+		locked = append(locked, lockedVersion{folder: node.folder, slug: node.slug, version: *node.version})
+	}
+
+	for slug, node := range r.nodes {
+		if node.tracked && len(node.requiredBy) > 0 {
+			if project, ok := trackedProject(slug, node.folder, r.conf); ok && len(project.DependencyOf) > 0 {
+				r.addDependents(slug, node.folder, node.requiredBy)
+			}
+		}
+	}
+
+	return locked, err
+}
+
+// addDependents adds projects to the dependency_of of a tracked project
+func (r *dependencyResolver) addDependents(slug, folder string, dependents []string) {
+	project, ok := trackedProject(slug, folder, r.conf)
+	if !ok {
+		return // optional folders aren't tracked
+	}
+	for _, dependent := range dependents {
+		if !slices.Contains(project.DependencyOf, dependent) {
+			project.DependencyOf = append(project.DependencyOf, dependent)
+			r.dirty = true
+		}
+	}
+	sort.Strings(project.DependencyOf)
+
+	switch folder {
+	case "mods":
+		r.conf.Mods[slug] = project
+	case "plugins":
+		r.conf.Plugins[slug] = project
+	}
+}
+
+// installDependencies resolves what a project version pulls in, installs it and records it in
+// osmium.json and the lock
+func installDependencies(slug, folder string, version projectVersion, conf *config.OsmiumConfig) error {
+	resolver := newDependencyResolver(conf)
+	resolver.addRoot(slug, folder, version)
+	if err := resolver.check(); err != nil {
+		return err
+	}
+	return resolver.record()
+}
+
+// record installs the planned dependencies and writes osmium.json and the lock when anything changed
+func (r *dependencyResolver) record() error {
+	locked, err := r.install()
+	if len(locked) == 0 && !r.dirty {
+		return err
+	}
+	if writeErr := config.WriteConfig(r.conf); writeErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to update osmium.json: %w", writeErr))
+	}
+	return errors.Join(err, recordLock(r.conf, locked...))
+}
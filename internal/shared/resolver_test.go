@@ -0,0 +1,207 @@
+package shared
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+)
+
+// fakeProject is a Modrinth project served by fakeProjects, its versions newest first
+type fakeProject struct {
+	id       string
+	slug     string
+	versions []modrinthVersion
+}
+
+// fakeVersion returns a version with one file, its SHA1 is "sha1-<id>"
+func fakeVersion(projectID, id string, deps ...dependency) modrinthVersion {
+	version := modrinthVersion{ID: id, ProjectID: projectID, VersionNumber: id, Dependencies: deps}
+	file := modrinthFile{URL: "https://cdn.modrinth.com/" + id + ".jar", Filename: id + ".jar", Primary: true}
+	file.Hashes.SHA1 = "sha1-" + id
+	version.Files = []modrinthFile{file}
+	return version
+}
+
+// fakeProjects serves the projects and their versions by ID, slug and file hash,
+// and runs the test in an empty server folder
+func fakeProjects(t *testing.T, projects ...fakeProject) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+
+	fakeModrinth(t, func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v2")
+		switch {
+		case r.Method == http.MethodPost && path == "/version_files":
+			var body struct {
+				Hashes []string `json:"hashes"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			found := make(map[string]modrinthVersion)
+			for _, project := range projects {
+				for _, version := range project.versions {
+					for _, hash := range body.Hashes {
+						if version.Files[0].Hashes.SHA1 == hash {
+							found[hash] = version
+						}
+					}
+				}
+			}
+			json.NewEncoder(w).Encode(found)
+			return
+		case strings.HasPrefix(path, "/version/"):
+			id := strings.TrimPrefix(path, "/version/")
+			for _, project := range projects {
+				for _, version := range project.versions {
+					if version.ID == id {
+						json.NewEncoder(w).Encode(version)
+						return
+					}
+				}
+			}
+		case strings.HasPrefix(path, "/project/"):
+			name, versions := strings.CutSuffix(strings.TrimPrefix(path, "/project/"), "/version")
+			for _, project := range projects {
+				if project.id != name && project.slug != name {
+					continue
+				}
+				if versions {
+					json.NewEncoder(w).Encode(project.versions)
+				} else {
+					json.NewEncoder(w).Encode(projectInfo{ID: project.id, Slug: project.slug, ServerSide: "required"})
+				}
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+}
+
+// fabricServer returns the config of a Fabric server tracking the given mods
+func fabricServer(mods map[string]config.Project) *config.OsmiumConfig {
+	if mods == nil {
+		mods = make(map[string]config.Project)
+	}
+	return &config.OsmiumConfig{Category: "Mod Loaders", Loader: "Fabric", Version: "1.21.1", Mods: mods, Plugins: make(map[string]config.Project)}
+}
+
+func requires(projectID string) dependency {
+	return dependency{ProjectID: projectID, DependencyType: "required"}
+}
+
+// plannedSlugs lists the dependencies the resolver would install, in order
+func plannedSlugs(r *dependencyResolver) []string {
+	var slugs []string
+	for _, node := range r.planned {
+		slugs = append(slugs, node.slug)
+	}
+	return slugs
+}
+
+// conflictsOf returns the conflicts of a check, failing the test on other errors
+func conflictsOf(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var conflicts *DependencyConflictError
+	if !errors.As(err, &conflicts) {
+		t.Fatalf("check failed with %v, want conflicts", err)
+	}
+	return conflicts.Conflicts
+}
+
+func TestResolverPinConflictBetweenRoots(t *testing.T) {
+	fakeProjects(t, fakeProject{id: "PL", slug: "lib", versions: []modrinthVersion{fakeVersion("PL", "lib-2"), fakeVersion("PL", "lib-1")}})
+
+	r := newDependencyResolver(fabricServer(nil))
+	r.addRoot("alpha", "mods", fakeVersion("PA", "alpha-1", dependency{ProjectID: "PL", VersionID: "lib-1", DependencyType: "required"}).projectVersion())
+	r.addRoot("beta", "mods", fakeVersion("PB", "beta-1", dependency{ProjectID: "PL", VersionID: "lib-2", DependencyType: "required"}).projectVersion())
+
+	conflicts := conflictsOf(t, r.check())
+	if len(conflicts) != 1 || !strings.Contains(conflicts[0], "beta needs lib version lib-2, but lib would be at lib-1") {
+		t.Errorf("got conflicts %q, want beta's pin of lib-2 to conflict", conflicts)
+	}
+}
+
+func TestResolverIncompatibleWithTracked(t *testing.T) {
+	fakeProjects(t,
+		fakeProject{id: "PO", slug: "optifine", versions: []modrinthVersion{fakeVersion("PO", "optifine-1")}},
+	)
+
+	conf := fabricServer(map[string]config.Project{
+		"optifine": {VersionNumber: "optifine-1", FileName: "optifine-1.jar", SHA1: "sha1-optifine-1"},
+	})
+	r := newDependencyResolver(conf)
+	r.addRoot("sodium", "mods", fakeVersion("PS", "sodium-1", dependency{ProjectID: "PO", DependencyType: "incompatible"}).projectVersion())
+
+	conflicts := conflictsOf(t, r.check())
+	if len(conflicts) != 1 || conflicts[0] != "sodium is incompatible with optifine" {
+		t.Errorf("got conflicts %q, want sodium to be incompatible with optifine", conflicts)
+	}
+}
+
+func TestResolverCycle(t *testing.T) {
+	fakeProjects(t,
+		fakeProject{id: "PA", slug: "alpha", versions: []modrinthVersion{fakeVersion("PA", "alpha-1", requires("PB"))}},
+		fakeProject{id: "PB", slug: "beta", versions: []modrinthVersion{fakeVersion("PB", "beta-1", requires("PA"))}},
+	)
+
+	r := newDependencyResolver(fabricServer(nil))
+	r.addRoot("alpha", "mods", fakeVersion("PA", "alpha-1", requires("PB")).projectVersion())
+
+	if conflicts := conflictsOf(t, r.check()); len(conflicts) > 0 {
+		t.Fatalf("got conflicts %q, a cycle isn't fatal", conflicts)
+	}
+	if planned := plannedSlugs(r); len(planned) != 1 || planned[0] != "beta" {
+		t.Errorf("planned %q, want beta installed once", planned)
+	}
+	if len(r.warnings) != 1 || r.warnings[0] != "dependency cycle: alpha → beta → alpha" {
+		t.Errorf("got warnings %q, want the cycle reported", r.warnings)
+	}
+}
+
+func TestResolverSkipDropsDependenciesOfFailedRoots(t *testing.T) {
+	fakeProjects(t,
+		fakeProject{id: "PB", slug: "beta", versions: []modrinthVersion{fakeVersion("PB", "beta-1", requires("PE"))}},
+		fakeProject{id: "PD", slug: "delta", versions: []modrinthVersion{fakeVersion("PD", "delta-1")}},
+		fakeProject{id: "PE", slug: "epsilon", versions: []modrinthVersion{fakeVersion("PE", "epsilon-1")}},
+	)
+
+	r := newDependencyResolver(fabricServer(nil))
+	r.addRoot("alpha", "mods", fakeVersion("PA", "alpha-1", requires("PB"), requires("PD")).projectVersion())
+	r.addRoot("gamma", "mods", fakeVersion("PC", "gamma-1", requires("PD")).projectVersion())
+	if err := r.check(); err != nil {
+		t.Fatal(err)
+	}
+	if planned := strings.Join(plannedSlugs(r), ","); planned != "beta,epsilon,delta" {
+		t.Fatalf("planned %s, want beta,epsilon,delta", planned)
+	}
+
+	// alpha failed to download: beta and what it pulls in go, delta is still needed by gamma
+	r.skip("alpha")
+	if planned := strings.Join(plannedSlugs(r), ","); planned != "delta" {
+		t.Errorf("planned %s after skipping alpha, want delta", planned)
+	}
+}
+
+func TestResolverMovesRequiredOutOfOptional(t *testing.T) {
+	fakeProjects(t, fakeProject{id: "PL", slug: "lib", versions: []modrinthVersion{fakeVersion("PL", "lib-1")}})
+
+	r := newDependencyResolver(fabricServer(nil))
+	r.addRoot("alpha", "mods", fakeVersion("PA", "alpha-1", dependency{ProjectID: "PL", DependencyType: "optional"}).projectVersion())
+	if folder := r.nodes["lib"].folder; folder != "optional_mods" {
+		t.Fatalf("lib goes to %s, want optional_mods for an optional dependency", folder)
+	}
+
+	r.addRoot("beta", "mods", fakeVersion("PB", "beta-1", requires("PL")).projectVersion())
+	if err := r.check(); err != nil {
+		t.Fatal(err)
+	}
+	if folder := r.nodes["lib"].folder; folder != "mods" {
+		t.Errorf("lib goes to %s once beta requires it, want mods", folder)
+	}
+}
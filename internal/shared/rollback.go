@@ -0,0 +1,307 @@
+package shared
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+)
+
+/*
+	Rollback points are taken before operations that replace jars (migrate, update).
+	Each point is a folder inside .osmium_rollback holding copies of osmium.json, its lock, the
+	server binary and every jar in the project folders, plus a manifest describing them.
+	Files are copied rather than hardlinked because downloads truncate files in place.
+*/
+
+const RollbackFolder = ".osmium_rollback"
+
+// maxRollbackPoints is how many points are kept before the oldest ones are deleted
+const maxRollbackPoints = 5
+
+// rollbackFiles are single files captured by every rollback point
+var rollbackFiles = []string{"osmium.json", config.LockFileName, "server.jar", "run.sh", "run.bat", "user_jvm_args.txt", filepath.Join("server", "server.jar")}
+
+// rollbackJarFolders are folders whose jars are captured and replaced as a whole
+var rollbackJarFolders = []string{"mods", "plugins", "optional_mods", "optional_plugins"}
+
+type rollbackManifest struct {
+	Operation string    `json:"operation"`
+	CreatedAt time.Time `json:"created_at"`
+	Files     []string  `json:"files"`   // files that existed and were copied
+	Missing   []string  `json:"missing"` // files that didn't exist and must be removed on rollback
+}
+
+// RollbackPoint describes a stored rollback point
+type RollbackPoint struct {
+	Name      string
+	Operation string
+	CreatedAt time.Time
+}
+
+// copyFile copies src to dst, creating parent folders as needed
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	// Replace rather than overwrite, dst can be a hardlink into the download cache
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// listJars returns the paths of all jar files directly inside folder
+func listJars(folder string) []string {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil
+	}
+
+	var jars []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(strings.ToLower(entry.Name()), ".jar") {
+			jars = append(jars, filepath.Join(folder, entry.Name()))
+		}
+	}
+
+	return jars
+}
+
+// claimRollbackFolder creates the folder of a new rollback point and returns its name. Points created
+// in the same second get a numbered suffix instead of overwriting each other.
+func claimRollbackFolder(base string) (string, error) {
+	if err := os.MkdirAll(RollbackFolder, 0755); err != nil {
+		return "", err
+	}
+	for i := 1; ; i++ {
+		name := base
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		err := os.Mkdir(filepath.Join(RollbackFolder, name), 0755)
+		if err == nil {
+			return name, nil
+		}
+		if !os.IsExist(err) {
+			return "", err
+		}
+	}
+}
+
+// CreateRollbackPoint copies osmium.json, the server binary and all project jars
+// so the current state can be restored with RollbackLast.
+func CreateRollbackPoint(operation string) (RollbackPoint, error) {
+	createdAt := time.Now()
+	name, err := claimRollbackFolder(fmt.Sprintf("%s-%s", createdAt.Format(backupTimeLayout), operation))
+	if err != nil {
+		return RollbackPoint{}, fmt.Errorf("failed to create rollback folder: %w", err)
+	}
+	pointFolder := filepath.Join(RollbackFolder, name)
+
+	manifest := rollbackManifest{Operation: operation, CreatedAt: createdAt}
+
+	var files []string
+	for _, file := range rollbackFiles {
+		if _, err := os.Stat(file); err == nil {
+			files = append(files, file)
+		} else {
+			manifest.Missing = append(manifest.Missing, filepath.ToSlash(file))
+		}
+	}
+	for _, folder := range rollbackJarFolders {
+		files = append(files, listJars(folder)...)
+	}
+
+	for _, file := range files {
+		if err := copyFile(file, filepath.Join(pointFolder, file)); err != nil {
+			os.RemoveAll(pointFolder)
+			return RollbackPoint{}, fmt.Errorf("failed to snapshot %s: %w", file, err)
+		}
+		manifest.Files = append(manifest.Files, filepath.ToSlash(file))
+	}
+
+	data, err := json.MarshalIndent(manifest, "", " ")
+	if err != nil {
+		os.RemoveAll(pointFolder)
+		return RollbackPoint{}, fmt.Errorf("failed to marshal rollback manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(pointFolder, "manifest.json"), data, 0644); err != nil {
+		os.RemoveAll(pointFolder)
+		return RollbackPoint{}, fmt.Errorf("failed to write rollback manifest: %w", err)
+	}
+
+	pruneRollbackPoints()
+
+	return RollbackPoint{Name: name, Operation: operation, CreatedAt: createdAt}, nil
+}
+
+func readRollbackManifest(name string) (rollbackManifest, error) {
+	data, err := os.ReadFile(filepath.Join(RollbackFolder, name, "manifest.json"))
+	if err != nil {
+		return rollbackManifest{}, err
+	}
+
+	var manifest rollbackManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return rollbackManifest{}, fmt.Errorf("failed to parse rollback manifest %s: %w", name, err)
+	}
+
+	return manifest, nil
+}
+
+// ListRollbackPoints returns the stored rollback points, newest first
+func ListRollbackPoints() ([]RollbackPoint, error) {
+	entries, err := os.ReadDir(RollbackFolder)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", RollbackFolder, err)
+	}
+
+	var points []RollbackPoint
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		manifest, err := readRollbackManifest(entry.Name())
+		if err != nil {
+			continue // incomplete point, ignore it
+		}
+
+		points = append(points, RollbackPoint{
+			Name:      entry.Name(),
+			Operation: manifest.Operation,
+			CreatedAt: manifest.CreatedAt,
+		})
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].CreatedAt.After(points[j].CreatedAt)
+	})
+
+	return points, nil
+}
+
+// pruneRollbackPoints deletes the oldest points beyond maxRollbackPoints
+func pruneRollbackPoints() {
+	points, err := ListRollbackPoints()
+	if err != nil {
+		return
+	}
+
+	for i := maxRollbackPoints; i < len(points); i++ {
+		os.RemoveAll(filepath.Join(RollbackFolder, points[i].Name))
+	}
+}
+
+// restoreRollbackPoint puts every file of a rollback point back in place
+func restoreRollbackPoint(name string) error {
+	manifest, err := readRollbackManifest(name)
+	if err != nil {
+		return fmt.Errorf("failed to read rollback point %s: %w", name, err)
+	}
+
+	// Drop jars added since the point was taken, the point holds the full set
+	for _, folder := range rollbackJarFolders {
+		for _, jar := range listJars(folder) {
+			if err := os.Remove(jar); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", jar, err)
+			}
+		}
+	}
+
+	for _, file := range manifest.Missing {
+		if err := os.Remove(filepath.FromSlash(file)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", file, err)
+		}
+	}
+
+	for _, file := range manifest.Files {
+		src := filepath.Join(RollbackFolder, name, filepath.FromSlash(file))
+		if err := copyFile(src, filepath.FromSlash(file)); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", file, err)
+		}
+	}
+
+	return nil
+}
+
+// RollbackLast restores the newest rollback point and removes it,
+// so calling it again steps further back.
+func RollbackLast() (RollbackPoint, error) {
+	if IsServerRunning() {
+		return RollbackPoint{}, fmt.Errorf("server is running, stop it with 'osmium stop' before rolling back")
+	}
+
+	points, err := ListRollbackPoints()
+	if err != nil {
+		return RollbackPoint{}, err
+	}
+	if len(points) == 0 {
+		return RollbackPoint{}, fmt.Errorf("no rollback points found")
+	}
+
+	last := points[0]
+	if err := restoreRollbackPoint(last.Name); err != nil {
+		return RollbackPoint{}, err
+	}
+
+	if err := os.RemoveAll(filepath.Join(RollbackFolder, last.Name)); err != nil {
+		return last, fmt.Errorf("restored, but failed to remove rollback point: %w", err)
+	}
+
+	return last, nil
+}
+
+// withRollbackPoint runs an operation after taking a rollback point.
+// If the operation fails, the point is restored automatically.
+func withRollbackPoint(operation string, fn func() error) error {
+	point, err := CreateRollbackPoint(operation)
+	if err != nil {
+		return fmt.Errorf("failed to create rollback point: %w", err)
+	}
+
+	if err := fn(); err != nil {
+		fmt.Printf("\n%s failed, restoring the previous state...\n", operation)
+		if restoreErr := restoreRollbackPoint(point.Name); restoreErr != nil {
+			return fmt.Errorf("%w (automatic rollback also failed: %v, run 'osmium rollback')", err, restoreErr)
+		}
+		os.RemoveAll(filepath.Join(RollbackFolder, point.Name))
+		fmt.Println("✓ Previous state restored")
+		return err
+	}
+
+	return nil
+}
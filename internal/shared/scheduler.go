@@ -0,0 +1,189 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/limelamp/osmium/internal/tui/storage"
+)
+
+// SchedulerHooks lets the host process provide actions the scheduler can't do on its own.
+// Restart and Send are nil when the server isn't supervised by this process. Restart stops the server,
+// runs whileStopped when it's set and starts the server again. Send writes a console command to the
+// server's stdin, without it commands go through the console socket.
+type SchedulerHooks struct {
+	Restart func(whileStopped func() error) error
+	Send    func(command string) error
+	Log     func(format string, args ...any)
+}
+
+// NewTaskID returns a short unique ID for a scheduled task
+func NewTaskID() string {
+	return fmt.Sprintf("task-%x", time.Now().UnixNano())
+}
+
+// ValidateTask checks that a task has a parseable cron expression and a known action
+func ValidateTask(task storage.ScheduledTask) error {
+	if _, err := ParseCron(task.Cron); err != nil {
+		return err
+	}
+
+	for _, action := range storage.TaskActions {
+		if task.Action == action {
+			if action == storage.TaskCommand && strings.TrimSpace(task.Argument) == "" {
+				return fmt.Errorf("command tasks need a console command")
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown action %q, expected one of %s", task.Action, strings.Join(storage.TaskActions, ", "))
+}
+
+// NextTaskRun computes when a task should run next after t
+func NextTaskRun(task storage.ScheduledTask, t time.Time) time.Time {
+	schedule, err := ParseCron(task.Cron)
+	if err != nil {
+		return time.Time{}
+	}
+	return schedule.Next(t)
+}
+
+// runTask executes a single scheduled task in the current directory
+func runTask(task storage.ScheduledTask, hooks SchedulerHooks) error {
+	send := hooks.Send
+	if send == nil {
+		send = SendConsoleCommand
+	}
+
+	switch task.Action {
+	case storage.TaskCommand:
+		return send(task.Argument)
+	case storage.TaskRestart:
+		if hooks.Restart == nil {
+			return fmt.Errorf("restart needs the server to be started with 'osmium run'")
+		}
+		return hooks.Restart(nil)
+	case storage.TaskBackup:
+		if task.Argument == "incremental" {
+			_, _, err := CreateIncrementalBackupWith(5*time.Second, send)
+			return err
+		}
+		_, err := CreateBackupWith(5*time.Second, send)
+		return err
+	case storage.TaskUpdate:
+		// Swapping jars under a running server breaks it, so the update runs while it's stopped
+		update := func() error { return UpdateAllProjects("all") }
+		if hooks.Restart != nil {
+			return hooks.Restart(update)
+		}
+		if IsServerRunning() {
+			return fmt.Errorf("update needs the server stopped, or started with 'osmium run' so it can be restarted around the update")
+		}
+		return update()
+	default:
+		return fmt.Errorf("unknown action %q", task.Action)
+	}
+}
+
+// runDueTasks runs every enabled task whose next run has passed and records the results
+func runDueTasks(store *storage.ServerStore, serverID string, now time.Time, hooks SchedulerHooks) error {
+	server, err := store.FindByID(serverID)
+	if err != nil {
+		return err
+	}
+
+	for _, task := range server.Tasks {
+		if !task.Enabled {
+			continue
+		}
+
+		// Tasks that were just added get their first run scheduled instead of firing immediately
+		if task.NextRun.IsZero() {
+			next := NextTaskRun(task, now)
+			if err := UpdateTask(store, serverID, task.ID, func(t *storage.ScheduledTask) { t.NextRun = next }); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if now.Before(task.NextRun) {
+			continue
+		}
+
+		hooks.Log("Running scheduled %s (%s)", task.Action, task.ID)
+		result := "ok"
+		if err := runTask(task, hooks); err != nil {
+			result = "error: " + err.Error()
+		}
+		hooks.Log("Scheduled %s finished: %s", task.Action, result)
+
+		finished := time.Now()
+		err := UpdateTask(store, serverID, task.ID, func(t *storage.ScheduledTask) {
+			t.LastRun = finished
+			t.LastResult = result
+			t.NextRun = NextTaskRun(*t, finished)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateTask modifies one task of a server in the store, a task removed in the meantime is left alone
+func UpdateTask(store *storage.ServerStore, serverID, taskID string, fn func(*storage.ScheduledTask)) error {
+	return store.Update(serverID, func(server *storage.Server) error {
+		for i := range server.Tasks {
+			if server.Tasks[i].ID == taskID {
+				fn(&server.Tasks[i])
+				return nil
+			}
+		}
+		return nil // removed while running, nothing to record
+	})
+}
+
+// rescheduleMissed moves runs that were due while nothing was supervising the server
+// to their next occurrence, so starting up doesn't fire a backlog of old tasks.
+func rescheduleMissed(store *storage.ServerStore, serverID string, now time.Time) error {
+	return store.Update(serverID, func(server *storage.Server) error {
+		for i := range server.Tasks {
+			if !server.Tasks[i].NextRun.IsZero() && server.Tasks[i].NextRun.Before(now) {
+				server.Tasks[i].NextRun = NextTaskRun(server.Tasks[i], now)
+			}
+		}
+		return nil
+	})
+}
+
+// RunScheduler checks the server's tasks once a minute until ctx is cancelled.
+// Tasks are re-read from the store on every tick, so edits made in the TUI or CLI apply immediately.
+func RunScheduler(ctx context.Context, store *storage.ServerStore, serverID string, hooks SchedulerHooks) {
+	if hooks.Log == nil {
+		hooks.Log = func(format string, args ...any) { fmt.Printf(format+"\n", args...) }
+	}
+
+	if err := rescheduleMissed(store, serverID, time.Now()); err != nil {
+		hooks.Log("Scheduler error: %v", err)
+	}
+
+	for {
+		if err := runDueTasks(store, serverID, time.Now(), hooks); err != nil {
+			hooks.Log("Scheduler error: %v", err)
+		}
+
+		// Wake up at the start of the next minute
+		now := time.Now()
+		wait := now.Truncate(time.Minute).Add(time.Minute).Sub(now)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
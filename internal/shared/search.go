@@ -0,0 +1,35 @@
+package shared
+
+import (
+	"fmt"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+)
+
+// SearchResult is one project returned by a search
+type SearchResult struct {
+	ProjectID   string   `json:"project_id"`
+	Slug        string   `json:"slug"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Categories  []string `json:"categories"`
+	Downloads   int      `json:"downloads"`
+	ServerSide  string   `json:"server_side"`
+	ClientSide  string   `json:"client_side"`
+}
+
+// SearchProjects searches a source for projects matching query that work with the server
+// in the current directory, most relevant first
+func SearchProjects(sourceName, query string, limit int) ([]SearchResult, error) {
+	source, err := getSource(sourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	osmiumConf, err := config.ReadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read osmium.json: %w", err)
+	}
+
+	return source.search(query, osmiumConf, limit)
+}
@@ -0,0 +1,188 @@
+package shared
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/limelamp/osmium/internal/tui/storage"
+)
+
+// ServiceOptions controls the generated systemd unit
+type ServiceOptions struct {
+	User    bool   // user unit (~/.config/systemd/user) instead of a system unit
+	Restart string // systemd Restart= policy: on-failure, always or no
+	RunAs   string // account for system units, empty means the invoking user
+}
+
+var serviceNameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// ServiceName returns the systemd unit name of a server, without the .service suffix
+func ServiceName(server storage.Server) string {
+	return "osmium-" + strings.Trim(serviceNameUnsafe.ReplaceAllString(server.ID, "-"), "-")
+}
+
+// ServiceUnitPath returns where the unit file of a server is installed
+func ServiceUnitPath(server storage.Server, userUnit bool) (string, error) {
+	if !userUnit {
+		return filepath.Join("/etc/systemd/system", ServiceName(server)+".service"), nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "systemd", "user", ServiceName(server)+".service"), nil
+}
+
+// serviceAccount returns the user a system unit runs as: the sudo caller if there is one
+func serviceAccount() string {
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		return sudoUser
+	}
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return ""
+}
+
+// BuildServiceUnit returns the systemd unit for a server. The server runs under 'osmium run' so the console
+// socket and scheduled tasks work. Stopping the unit sends 'stop' through the server's own console socket
+// so the world is saved. If that fails, the supervisor gets SIGTERM and sends 'stop' itself.
+func BuildServiceUnit(server storage.Server, executable string, opts ServiceOptions) string {
+	restart := opts.Restart
+	if restart == "" {
+		restart = "on-failure"
+	}
+	if strings.ContainsAny(executable, " \t") {
+		executable = `"` + executable + `"`
+	}
+
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	fmt.Fprintf(&b, "Description=Minecraft server %s (Osmium)\n", server.Name)
+	b.WriteString("After=network-online.target\n")
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=simple\n")
+	if !opts.User {
+		runAs := opts.RunAs
+		if runAs == "" {
+			runAs = serviceAccount()
+		}
+		if runAs != "" {
+			fmt.Fprintf(&b, "User=%s\n", runAs)
+		}
+	}
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", server.Path)
+	fmt.Fprintf(&b, "ExecStart=%s run --stop-timeout 60s\n", executable)
+	fmt.Fprintf(&b, "ExecStop=%s exec --server %s stop\n", executable, server.ID)
+	// Only the supervisor gets SIGTERM after ExecStop, it waits for java to finish shutting down
+	b.WriteString("KillMode=mixed\n")
+	b.WriteString("TimeoutStopSec=90\n")
+	fmt.Fprintf(&b, "Restart=%s\n", restart)
+	b.WriteString("RestartSec=10\n")
+	b.WriteString("\n[Install]\n")
+	if opts.User {
+		b.WriteString("WantedBy=default.target\n")
+	} else {
+		b.WriteString("WantedBy=multi-user.target\n")
+	}
+
+	return b.String()
+}
+
+// systemctl runs systemctl for a user or system unit, returning its combined output
+func systemctl(userUnit bool, args ...string) (string, error) {
+	if userUnit {
+		args = append([]string{"--user"}, args...)
+	}
+	output, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("systemctl %s failed: %w\n%s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// InstallService writes the unit file of a server and enables it, starting it unless start is false
+func InstallService(server storage.Server, opts ServiceOptions, start bool) (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the osmium binary: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return "", err
+	}
+
+	unitPath, err := ServiceUnitPath(server, opts.User)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(unitPath), 0755); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(unitPath, []byte(BuildServiceUnit(server, executable, opts)), 0644); err != nil {
+		if os.IsPermission(err) {
+			return "", fmt.Errorf("failed to write %s: %w (system units need root, or use --user)", unitPath, err)
+		}
+		return "", fmt.Errorf("failed to write %s: %w", unitPath, err)
+	}
+
+	if _, err := systemctl(opts.User, "daemon-reload"); err != nil {
+		return unitPath, err
+	}
+
+	enableArgs := []string{"enable", ServiceName(server)}
+	if start {
+		enableArgs = []string{"enable", "--now", ServiceName(server)}
+	}
+	if _, err := systemctl(opts.User, enableArgs...); err != nil {
+		return unitPath, err
+	}
+
+	return unitPath, nil
+}
+
+// UninstallService stops and disables a server's unit and removes the unit file
+func UninstallService(server storage.Server, userUnit bool) error {
+	unitPath, err := ServiceUnitPath(server, userUnit)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(unitPath); os.IsNotExist(err) {
+		return fmt.Errorf("no service installed for %s (%s)", server.Name, unitPath)
+	}
+
+	// A unit that's already stopped or disabled is fine
+	_, _ = systemctl(userUnit, "disable", "--now", ServiceName(server))
+
+	if err := os.Remove(unitPath); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", unitPath, err)
+	}
+
+	_, err = systemctl(userUnit, "daemon-reload")
+	return err
+}
+
+// ServiceStatus returns the output of 'systemctl status' for a server's unit
+func ServiceStatus(server storage.Server, userUnit bool) (string, error) {
+	unitPath, err := ServiceUnitPath(server, userUnit)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(unitPath); os.IsNotExist(err) {
+		return "", fmt.Errorf("no service installed for %s (%s)", server.Name, unitPath)
+	}
+
+	// systemctl status exits non-zero for stopped units, the output is still what we want
+	output, err := systemctl(userUnit, "status", "--no-pager", ServiceName(server))
+	if output != "" {
+		return output, nil
+	}
+	return "", err
+}
@@ -0,0 +1,84 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/limelamp/osmium/internal/tui/storage"
+)
+
+func TestBuildServiceUnit(t *testing.T) {
+	server := storage.Server{ID: "survival", Name: "Survival", Path: "/srv/minecraft/survival"}
+
+	tests := []struct {
+		name       string
+		executable string
+		opts       ServiceOptions
+		want       []string
+		wantNot    []string
+	}{
+		{
+			name:       "system unit",
+			executable: "/usr/local/bin/osmium",
+			opts:       ServiceOptions{RunAs: "minecraft"},
+			want: []string{
+				"Description=Minecraft server Survival (Osmium)\n",
+				"User=minecraft\n",
+				"WorkingDirectory=/srv/minecraft/survival\n",
+				"ExecStart=/usr/local/bin/osmium run --stop-timeout 60s\n",
+				"ExecStop=/usr/local/bin/osmium exec --server survival stop\n",
+				"KillMode=mixed\n",
+				"Restart=on-failure\n",
+				"WantedBy=multi-user.target\n",
+			},
+			wantNot: []string{"WantedBy=default.target"},
+		},
+		{
+			name:       "user unit",
+			executable: "/home/steve/bin/osmium",
+			opts:       ServiceOptions{User: true, Restart: "always", RunAs: "minecraft"},
+			want: []string{
+				"ExecStart=/home/steve/bin/osmium run --stop-timeout 60s\n",
+				"ExecStop=/home/steve/bin/osmium exec --server survival stop\n",
+				"Restart=always\n",
+				"WantedBy=default.target\n",
+			},
+			wantNot: []string{"User=", "WantedBy=multi-user.target"},
+		},
+		{
+			name:       "executable with spaces",
+			executable: "/opt/my tools/osmium",
+			opts:       ServiceOptions{User: true, Restart: "no"},
+			want: []string{
+				`ExecStart="/opt/my tools/osmium" run --stop-timeout 60s` + "\n",
+				`ExecStop="/opt/my tools/osmium" exec --server survival stop` + "\n",
+				"Restart=no\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := BuildServiceUnit(server, tt.executable, tt.opts)
+
+			if !strings.HasPrefix(unit, "[Unit]\n") {
+				t.Errorf("unit doesn't start with [Unit]:\n%s", unit)
+			}
+			for _, section := range []string{"\n[Service]\n", "\n[Install]\n"} {
+				if !strings.Contains(unit, section) {
+					t.Errorf("unit is missing %q:\n%s", strings.TrimSpace(section), unit)
+				}
+			}
+			for _, line := range tt.want {
+				if !strings.Contains(unit, line) {
+					t.Errorf("unit is missing %q:\n%s", strings.TrimSpace(line), unit)
+				}
+			}
+			for _, line := range tt.wantNot {
+				if strings.Contains(unit, line) {
+					t.Errorf("unit shouldn't contain %q:\n%s", line, unit)
+				}
+			}
+		})
+	}
+}
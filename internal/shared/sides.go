@@ -0,0 +1,174 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+	"github.com/limelamp/osmium/internal/util"
+)
+
+/*
+	Modrinth records which side every project runs on ("client_side" and "server_side": required,
+	optional, unsupported or unknown). Client-only mods like shaders, minimaps or Sodium crash
+	Forge servers, so projects with server_side "unsupported" are refused unless AllowClientOnly is set.
+	Other sources don't say, their projects aren't checked.
+*/
+
+// ErrClientOnly is returned for projects that don't run on servers
+var ErrClientOnly = errors.New("is client-side only")
+
+// AllowClientOnly installs client-only projects with a warning instead of refusing them, set by --allow-client
+var AllowClientOnly bool
+
+// clientOnly reports whether the project doesn't run on servers
+func (info projectInfo) clientOnly() bool {
+	return info.ServerSide == "unsupported"
+}
+
+// checkServerSide refuses client-only projects unless AllowClientOnly is set
+func checkServerSide(info projectInfo) error {
+	if !info.clientOnly() {
+		return nil
+	}
+	if AllowClientOnly {
+		fmt.Printf("⚠ %s is client-side only and may crash the server, installing it anyway\n", info.Slug)
+		return nil
+	}
+	return fmt.Errorf("%s %w and may crash the server, use --allow-client to install it anyway", info.Slug, ErrClientOnly)
+}
+
+// resolveForServer returns the slug of a project ID or slug, refusing Modrinth projects that don't run on servers
+func resolveForServer(source projectSource, projectID string) (string, error) {
+	if source.name() != SourceModrinth {
+		return source.resolve(projectID)
+	}
+
+	info, err := getProjectInfo(projectID)
+	if err != nil {
+		return "", err
+	}
+	if err := checkServerSide(info); err != nil {
+		return "", err
+	}
+	return info.Slug, nil
+}
+
+// SideReportEntry is a jar that may not run on the server
+type SideReportEntry struct {
+	Slug       string `json:"slug"`
+	Folder     string `json:"folder"`
+	FileName   string `json:"filename"`
+	ClientSide string `json:"client_side"`
+	ServerSide string `json:"server_side"`
+	Tracked    bool   `json:"tracked"` // in osmium.json
+}
+
+// SideReport lists the mods and plugins of a server that don't run on servers, or might not
+type SideReport struct {
+	Checked    int               `json:"checked"`   // jars Modrinth knows
+	Unchecked  int               `json:"unchecked"` // jars from other sources or unknown to Modrinth
+	ClientOnly []SideReportEntry `json:"client_only"`
+	Unknown    []SideReportEntry `json:"unknown"` // Modrinth doesn't know whether they run on servers
+}
+
+// CheckSides looks up every jar in mods/ and plugins/ on Modrinth by hash, tracked or not,
+// and reports the ones that are client-side only
+func CheckSides() (SideReport, error) {
+	if util.Offline {
+		return SideReport{}, fmt.Errorf("checking which side projects run on needs Modrinth, it doesn't work offline")
+	}
+
+	osmiumConf, err := config.ReadConfig()
+	if err != nil {
+		return SideReport{}, fmt.Errorf("failed to read osmium.json: %w", err)
+	}
+
+	// Step 1: Hash every jar
+	var jars []trackedJar
+	for _, folder := range []string{"mods", "plugins"} {
+		for _, path := range listJars(folder) {
+			jars = append(jars, trackedJar{folder: folder, path: path})
+		}
+	}
+	if err := forEachOrdered(len(jars), func(i int, out io.Writer) error {
+		checksum, err := calculateSHA1(jars[i].path)
+		if err != nil {
+			return fmt.Errorf("failed to calculate checksum for %s: %w", jars[i].path, err)
+		}
+		jars[i].sha1 = checksum
+		return nil
+	}); err != nil {
+		fmt.Println(err)
+	}
+
+	// Step 2: Look up the files, then their projects
+	var hashes []string
+	for _, jar := range jars {
+		if jar.sha1 != "" {
+			hashes = append(hashes, jar.sha1)
+		}
+	}
+	versions, err := getVersionsByHash(hashes)
+	if err != nil {
+		return SideReport{}, err
+	}
+
+	var ids []string
+	seen := make(map[string]bool)
+	for _, version := range versions {
+		if !seen[version.ProjectID] {
+			seen[version.ProjectID] = true
+			ids = append(ids, version.ProjectID)
+		}
+	}
+	projects, err := getProjectsByID(ids)
+	if err != nil {
+		return SideReport{}, err
+	}
+
+	// Step 3: Sort the jars by side
+	slugs := make(map[string]string) // file name to tracked slug
+	for _, entry := range trackedEntries(osmiumConf) {
+		slugs[entry.folder+"/"+entry.project.FileName] = entry.slug
+	}
+
+	var report SideReport
+	for _, jar := range jars {
+		version, ok := versions[jar.sha1]
+		info, known := projects[version.ProjectID]
+		if !ok || !known {
+			report.Unchecked++
+			continue
+		}
+		report.Checked++
+
+		fileName := filepath.Base(jar.path)
+		slug, tracked := slugs[jar.folder+"/"+fileName]
+		if !tracked {
+			slug = info.Slug
+		}
+		entry := SideReportEntry{
+			Slug:       slug,
+			Folder:     jar.folder,
+			FileName:   fileName,
+			ClientSide: info.ClientSide,
+			ServerSide: info.ServerSide,
+			Tracked:    tracked,
+		}
+
+		switch {
+		case info.clientOnly():
+			report.ClientOnly = append(report.ClientOnly, entry)
+		case info.ServerSide == "unknown":
+			report.Unknown = append(report.Unknown, entry)
+		}
+	}
+
+	sort.Slice(report.ClientOnly, func(i, j int) bool { return report.ClientOnly[i].Slug < report.ClientOnly[j].Slug })
+	sort.Slice(report.Unknown, func(i, j int) bool { return report.Unknown[i].Slug < report.Unknown[j].Slug })
+	return report, nil
+}
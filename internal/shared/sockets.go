@@ -5,15 +5,28 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"os"
 )
 
+// ConsoleSocketName is the unix socket in the server directory the running server reads console commands from.
+// Every server has its own, so a command never reaches another server on the same host.
+const ConsoleSocketName = ".osmium_console.sock"
+
 // This server can only accept inputs, cannot send outputs.
-func StartBasicSocketServer(inputPipe io.WriteCloser) {
+func StartBasicSocketServer(inputPipe io.Writer) {
+
+	// A socket that still answers belongs to another process running this server, one that doesn't is stale
+	if conn, err := net.Dial("unix", ConsoleSocketName); err == nil {
+		conn.Close()
+		fmt.Printf("Error: Could not start listener. %s is in use by another process.\n", ConsoleSocketName)
+		return
+	}
+	os.Remove(ConsoleSocketName)
 
 	// Main action
-	l, err := net.Listen("tcp", "127.0.0.1:59072")
+	l, err := net.Listen("unix", ConsoleSocketName)
 	if err != nil {
-		fmt.Println("Error: Could not start listener. Is port 59072 in use?")
+		fmt.Printf("Error: Could not start listener on %s: %v\n", ConsoleSocketName, err)
 		return
 	}
 	defer l.Close() // Ensure listener is closed properly when program exits.
@@ -36,3 +49,19 @@ func StartBasicSocketServer(inputPipe io.WriteCloser) {
 		}(conn)
 	}
 }
+
+// SendConsoleCommand writes a single command to the console of the server in the current directory
+// through the socket opened by StartBasicSocketServer.
+func SendConsoleCommand(command string) error {
+	conn, err := net.Dial("unix", ConsoleSocketName)
+	if err != nil {
+		return fmt.Errorf("server is not running (couldn't connect to socket): %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := fmt.Fprintln(conn, command); err != nil {
+		return fmt.Errorf("failed to send command: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package shared
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+	"github.com/limelamp/osmium/internal/util"
+)
+
+/*
+	A project source is a site mods and plugins are installed from. Modrinth is the default,
+	every project in osmium.json records the source it came from ("source", empty means Modrinth),
+	so installing, updating and migrating ask the right site for it.
+
+	Projects can also come straight from a URL or a local file. Those can't be searched or updated,
+	unless they follow the releases of a GitHub repository.
+*/
+
+// Names of the project sources, as written in osmium.json and passed to --source
+const (
+	SourceModrinth   = "modrinth"
+	SourceHangar     = "hangar"
+	SourceCurseForge = "curseforge"
+	SourceURL        = "url"
+	SourceFile       = "file"
+	SourceGitHub     = "github"
+)
+
+// projectSource is a site projects are installed from
+type projectSource interface {
+	// name is how the source is written in osmium.json
+	name() string
+	// search finds projects for the server, most relevant first
+	search(query string, conf *config.OsmiumConfig, limit int) ([]SearchResult, error)
+	// resolve returns the slug of a project ID or slug
+	resolve(projectID string) (string, error)
+	// versions returns the versions compatible with the server, newest first
+	versions(slug string, conf *config.OsmiumConfig) ([]projectVersion, error)
+}
+
+var projectSources = map[string]projectSource{
+	SourceModrinth:   modrinthSource{},
+	SourceHangar:     hangarSource{},
+	SourceCurseForge: curseforgeSource{},
+}
+
+// directSources are where projects added with --url, --file or --github come from, they can't be searched
+var directSources = map[string]projectSource{
+	SourceURL:    directSource{source: SourceURL, origin: "a URL"},
+	SourceFile:   directSource{source: SourceFile, origin: "a local file"},
+	SourceGitHub: githubSource{},
+}
+
+// projectVersion is a version resolved by a source: the file it installs and what it requires
+type projectVersion struct {
+	Source        string
+	ProjectID     string
+	ID            string
+	VersionNumber string
+	FileName      string
+	URL           string
+	ExternalURL   string // set instead of URL when the file has to be downloaded by hand
+	Checksums     util.Checksums
+	Dependencies  []dependency
+
+	Origin string // local file the version was added from
+	Repo   string // owner/repo of the GitHub releases it follows
+	Asset  string // glob of its file in those releases
+}
+
+// ManualDownloadError is returned for a file that can't be downloaded automatically, because its
+// author doesn't allow third-party downloads or only publishes it on another site
+type ManualDownloadError struct {
+	Project  string
+	FileName string
+	URL      string
+	Folder   string
+}
+
+func (e *ManualDownloadError) Error() string {
+	return fmt.Sprintf("%s has to be downloaded manually: get %s from %s and put it in %s/", e.Project, e.FileName, e.URL, e.Folder)
+}
+
+// SourceNames returns the names of the available project sources
+func SourceNames() []string {
+	names := make([]string, 0, len(projectSources))
+	for name := range projectSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getSource returns the source with the given name, Modrinth when it's empty
+func getSource(name string) (projectSource, error) {
+	if name == "" {
+		name = SourceModrinth
+	}
+	source, ok := projectSources[strings.ToLower(name)]
+	if !ok {
+		source, ok = directSources[strings.ToLower(name)]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown project source %q (available: %s)", name, strings.Join(SourceNames(), ", "))
+	}
+	return source, nil
+}
+
+// sourceName returns the source a project in osmium.json came from
+func sourceName(project config.Project) string {
+	if project.Source == "" {
+		return SourceModrinth
+	}
+	return project.Source
+}
+
+// trackedProject returns the osmium.json entry of a project, if any
+func trackedProject(slug, folder string, conf *config.OsmiumConfig) (config.Project, bool) {
+	var project config.Project
+	var ok bool
+	switch folder {
+	case "mods", "optional_mods":
+		project, ok = conf.Mods[slug]
+	case "plugins", "optional_plugins":
+		project, ok = conf.Plugins[slug]
+	}
+	return project, ok
+}
+
+// sourceOf returns the source of a tracked project, Modrinth for projects that aren't tracked
+func sourceOf(slug, folder string, conf *config.OsmiumConfig) (projectSource, error) {
+	project, _ := trackedProject(slug, folder, conf)
+	return getSource(project.Source)
+}
+
+// downloadVersion downloads the file of a version into the folder, or links it from the download cache.
+// Sources that don't publish a SHA1 get it filled in from the downloaded file, osmium.json pins files by SHA1.
+func downloadVersion(version *projectVersion, folder string) error {
+	if version.URL == "" {
+		if version.ExternalURL != "" {
+			return &ManualDownloadError{Project: version.ProjectID, FileName: version.FileName, URL: version.ExternalURL, Folder: folder}
+		}
+		return fmt.Errorf("no files found in %s %s", version.ProjectID, version.VersionNumber)
+	}
+
+	path := filepath.Join(folder, version.FileName)
+	if err := util.DownloadCached(version.URL, path, version.Checksums); err != nil {
+		return err
+	}
+
+	if version.Checksums.SHA1 == "" {
+		checksum, err := calculateSHA1(path)
+		if err != nil {
+			return err
+		}
+		version.Checksums.SHA1 = checksum
+	}
+	return nil
+}
+
+// isLatestVersion reports whether a tracked project is already at the version
+func isLatestVersion(project config.Project, version projectVersion) bool {
+	if version.Checksums.SHA1 != "" {
+		return strings.EqualFold(project.SHA1, version.Checksums.SHA1)
+	}
+	return project.FileName == version.FileName && project.VersionNumber == version.VersionNumber
+}
@@ -0,0 +1,215 @@
+package shared
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Supervisor owns the server process: it starts it, forwards console input to it,
+// and can stop or restart it without the socket or lock file going stale.
+type Supervisor struct {
+	loader string
+	output io.Writer
+
+	mu         sync.Mutex
+	cmd        *exec.Cmd
+	stdin      io.WriteCloser
+	done       chan struct{} // closed when the current process exits
+	exitErr    error
+	restarting bool
+}
+
+// NewSupervisor prepares a supervisor for a server of the given loader.
+// Server output (stdout and stderr) is written to output.
+func NewSupervisor(loader string, output io.Writer) *Supervisor {
+	return &Supervisor{loader: loader, output: output}
+}
+
+// Start launches the server process in the current directory
+func (s *Supervisor) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cmd != nil && s.done != nil {
+		select {
+		case <-s.done:
+		default:
+			return fmt.Errorf("server is already running")
+		}
+	}
+
+	if pid, err := ReadLockPID(); err == nil {
+		if IsPIDRunning(pid) {
+			return fmt.Errorf("server already running (pid %d). stop it with 'osmium stop'", pid)
+		}
+		if err := RemoveLockFile(); err != nil {
+			return err
+		}
+	}
+
+	cmd, err := NewServerCommand(s.loader, s.output)
+	if err != nil {
+		return err
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	if err := WriteLockPID(cmd.Process.Pid); err != nil {
+		_ = cmd.Process.Kill()
+		return err
+	}
+
+	done := make(chan struct{})
+	s.cmd = cmd
+	s.stdin = stdin
+	s.done = done
+
+	go func() {
+		err := cmd.Wait()
+		_ = RemoveLockFile()
+
+		s.mu.Lock()
+		s.exitErr = err
+		s.mu.Unlock()
+		close(done)
+	}()
+
+	return nil
+}
+
+// Write sends console input to the running server, so the supervisor can be
+// handed to StartBasicSocketServer and keeps working across restarts.
+func (s *Supervisor) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	stdin := s.stdin
+	s.mu.Unlock()
+
+	if stdin == nil {
+		return 0, fmt.Errorf("server is not running")
+	}
+	return stdin.Write(p)
+}
+
+// Send writes a console command to the running server
+func (s *Supervisor) Send(command string) error {
+	_, err := fmt.Fprintln(s, command)
+	return err
+}
+
+// Stop asks the server to save and shut down, killing it if it doesn't exit within timeout
+func (s *Supervisor) Stop(timeout time.Duration) error {
+	s.mu.Lock()
+	cmd, done := s.cmd, s.done
+	s.mu.Unlock()
+
+	if cmd == nil {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil // already exited
+	default:
+	}
+
+	if _, err := fmt.Fprintln(s, "stop"); err != nil {
+		return err
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		fmt.Fprintln(s.output, "Server did not stop in time, killing it")
+		if err := cmd.Process.Kill(); err != nil {
+			return fmt.Errorf("failed to kill server: %w", err)
+		}
+		<-done
+		return nil
+	}
+}
+
+// Kill terminates the server immediately
+func (s *Supervisor) Kill() error {
+	s.mu.Lock()
+	cmd := s.cmd
+	s.mu.Unlock()
+
+	if cmd == nil || cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
+
+// Restart stops the server gracefully and starts it again
+func (s *Supervisor) Restart(timeout time.Duration) error {
+	return s.RestartWith(timeout, nil)
+}
+
+// RestartWith stops the server gracefully, runs whileStopped and starts the server again.
+// The server is started even when whileStopped fails, its error is returned.
+func (s *Supervisor) RestartWith(timeout time.Duration, whileStopped func() error) error {
+	s.mu.Lock()
+	s.restarting = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.restarting = false
+		s.mu.Unlock()
+	}()
+
+	if err := s.Stop(timeout); err != nil {
+		return err
+	}
+
+	var stoppedErr error
+	if whileStopped != nil {
+		stoppedErr = whileStopped()
+	}
+
+	if err := s.Start(); err != nil {
+		return err
+	}
+	return stoppedErr
+}
+
+// Wait blocks until the server exits for a reason other than a restart
+// and returns the process exit error.
+func (s *Supervisor) Wait() error {
+	for {
+		s.mu.Lock()
+		done := s.done
+		s.mu.Unlock()
+
+		if done == nil {
+			return fmt.Errorf("server was never started")
+		}
+
+		<-done
+
+		s.mu.Lock()
+		restarting, replaced, exitErr := s.restarting, s.done != done, s.exitErr
+		s.mu.Unlock()
+
+		if restarting {
+			time.Sleep(100 * time.Millisecond) // wait for the new process to come up
+			continue
+		}
+		if replaced {
+			continue
+		}
+
+		return exitErr
+	}
+}
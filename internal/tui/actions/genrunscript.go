@@ -2,13 +2,26 @@ package actions
 
 import (
 	"fmt"
-	"os"
-	"runtime"
+	"slices"
+	"strings"
 
+	"charm.land/bubbles/v2/textinput"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/limelamp/osmium/internal/shared"
+	"github.com/limelamp/osmium/internal/tui/config"
 	"github.com/limelamp/osmium/internal/tui/core"
 	"github.com/limelamp/osmium/internal/tui/styles"
+	"github.com/limelamp/osmium/internal/util"
+)
+
+type ScriptField int // Fields of the "Detailed" form
+const (
+	FieldMemory ScriptField = iota
+	FieldProfile
+	FieldJavaPath
+	FieldRestart
+	FieldGenerate
 )
 
 // RunScript Model
@@ -20,22 +33,100 @@ type GenRunScriptModel struct {
 	options []string
 	GoBack  bool
 	err     error
+	status  string
+
+	// Detailed form
+	detailed    bool
+	field       ScriptField
+	memoryInput textinput.Model
+	javaInput   textinput.Model
+	profile     int
+	restart     bool
 }
 
 func NewGenRunScriptModel() GenRunScriptModel {
+	// Prefill the form with the server's saved settings
+	opts := shared.GetRunOptions()
+
+	memory, err := util.NormalizeMemory(opts.Memory)
+	if err != nil {
+		memory = util.DefaultMemory
+	}
+	profile := slices.Index(util.JVMProfiles, opts.Profile)
+	if profile == -1 {
+		profile = slices.Index(util.JVMProfiles, util.DefaultJVMProfile)
+	}
+
+	memoryInput := textinput.New()
+	memoryInput.Placeholder = util.DefaultMemory
+	memoryInput.CharLimit = 10
+	memoryInput.SetWidth(10)
+	memoryInput.SetValue(memory)
+
+	javaInput := textinput.New()
+	javaInput.Placeholder = "java (from PATH)"
+	javaInput.CharLimit = 500
+	javaInput.SetWidth(50)
+	javaInput.SetValue(opts.JavaPath)
+
 	return GenRunScriptModel{
-		cursor:  0,
-		options: []string{"Recommended settings", "Detailed"},
-		GoBack:  false,
+		cursor:      0,
+		options:     []string{"Recommended settings", "Detailed"},
+		GoBack:      false,
+		memoryInput: memoryInput,
+		javaInput:   javaInput,
+		profile:     profile,
+		restart:     true,
 	}
 }
 
+// writeScripts generates run_server.sh and run_server.bat with the given options
+func (m GenRunScriptModel) writeScripts(run util.RunOptions, restart bool) GenRunScriptModel {
+	osmiumConf, err := config.ReadConfig()
+	if err != nil {
+		m.err = err
+		return m
+	}
+
+	written, err := util.WriteRunScripts(util.RunScriptOptions{
+		Loader:         osmiumConf.Loader,
+		Run:            run,
+		RestartOnCrash: restart,
+	})
+	if err != nil {
+		m.err = err
+		return m
+	}
+
+	m.err = nil
+	m.status = "✓ Created " + strings.Join(written, ", ")
+	return m
+}
+
+// focusField moves the text cursor to the selected form field
+func (m *GenRunScriptModel) focusField() tea.Cmd {
+	m.memoryInput.Blur()
+	m.javaInput.Blur()
+
+	switch m.field {
+	case FieldMemory:
+		return m.memoryInput.Focus()
+	case FieldJavaPath:
+		return m.javaInput.Focus()
+	}
+	return nil
+}
+
 // RunScript State
 func (m GenRunScriptModel) Init() tea.Cmd {
 	return nil
 }
 
 func (m GenRunScriptModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.detailed {
+		return m.updateDetailed(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -55,40 +146,82 @@ func (m GenRunScriptModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			switch m.cursor {
 			case 0: // Recommended settings
-				const globalContent = "java -jar -Xms4G server.jar nogui"
-				var content []byte
-				var outputFile string
-				// Create a very basic bash script
-				switch runtime.GOOS { // Create different files and contents for different OS
-				case "linux":
-					content = []byte("#!/bin/bash\n\n" + globalContent)
-					outputFile = "run_server.sh"
-				case "windows":
-					content = []byte(globalContent)
-					outputFile = "run_server.bat"
-				case "darwin":
-					content = []byte("#!/bin/sh\n\n" + globalContent)
-					outputFile = "run_server.sh"
-				case "freebsd":
-					content = []byte("#!/bin/bash\n\n" + globalContent)
-					outputFile = "run_server.sh"
-				default:
-					fmt.Println("Unsupported OS!")
-					return m, nil
-				}
+				// Same JVM settings the TUI and 'osmium run' launch with
+				m = m.writeScripts(shared.GetRunOptions(), false)
+			case 1: // Detailed
+				m.detailed = true
+				m.status = ""
+				m.field = FieldMemory
+				return m, m.focusField()
+			}
+		}
+	}
+	return m, nil
+}
 
-				// Create the file
-				err := os.WriteFile(outputFile, content, 0755)
-				if err != nil {
-					m.err = err
-					return m, nil
+func (m GenRunScriptModel) updateDetailed(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "ctrl+h": // ctrl+backspace
+			m.detailed = false
+			m.memoryInput.Blur()
+			m.javaInput.Blur()
+			return m, nil
+		case "up", "shift+tab":
+			if m.field > FieldMemory {
+				m.field--
+			}
+			return m, m.focusField()
+		case "down", "tab":
+			if m.field < FieldGenerate {
+				m.field++
+			}
+			return m, m.focusField()
+		case "left", "right", "space":
+			switch m.field {
+			case FieldProfile:
+				if msg.String() == "left" {
+					m.profile = (m.profile + len(util.JVMProfiles) - 1) % len(util.JVMProfiles)
+				} else {
+					m.profile = (m.profile + 1) % len(util.JVMProfiles)
 				}
+				return m, nil
+			case FieldRestart:
+				m.restart = !m.restart
+				return m, nil
+			}
+		case "enter":
+			if m.field != FieldGenerate {
+				m.field++
+				return m, m.focusField()
+			}
 
-				fmt.Println("File Created!")
+			run := util.RunOptions{
+				Memory:   m.memoryInput.Value(),
+				Profile:  util.JVMProfiles[m.profile],
+				Flags:    shared.GetRunOptions().Flags,
+				JavaPath: strings.TrimSpace(m.javaInput.Value()),
 			}
+			// Saved with the server, 'osmium run' rewrites user_jvm_args.txt from servers.json
+			if err := shared.SaveRunOptions(run); err != nil {
+				m.err = err
+				return m, nil
+			}
+			m = m.writeScripts(run, m.restart)
+			return m, nil
 		}
 	}
-	return m, nil
+
+	var cmd tea.Cmd
+	switch m.field {
+	case FieldMemory:
+		m.memoryInput, cmd = m.memoryInput.Update(msg)
+	case FieldJavaPath:
+		m.javaInput, cmd = m.javaInput.Update(msg)
+	}
+	return m, cmd
 }
 
 // RunScript View
@@ -100,17 +233,24 @@ func (m GenRunScriptModel) View() tea.View {
 			Bold(true)
 		content += errorStyle.Render("Error: "+m.err.Error()) + "\n\n"
 	}
+	if m.status != "" {
+		content += m.status + "\n\n"
+	}
 
-	// Create a simple list
-	for i := 0; i < len(m.options); i++ {
-		cursor := "  "
-		if m.cursor == i {
-			cursor = "> "
+	if m.detailed {
+		content += m.detailedView()
+	} else {
+		// Create a simple list
+		for i := 0; i < len(m.options); i++ {
+			cursor := "  "
+			if m.cursor == i {
+				cursor = "> "
+			}
+			content += fmt.Sprintf("%s %s\n", cursor, m.options[i])
 		}
-		content += fmt.Sprintf("%s %s\n", cursor, m.options[i])
-	}
 
-	content += "\n\n" + "Navigate using arrow keys. Press 'q' to exit, 'backspace' to go back.\n\n"
+		content += "\n\n" + "Navigate using arrow keys. Press 'q' to exit, 'backspace' to go back.\n\n"
+	}
 
 	return tea.NewView(styles.Container(
 		m.layout.Width,
@@ -122,6 +262,42 @@ func (m GenRunScriptModel) View() tea.View {
 	))
 }
 
+func (m GenRunScriptModel) detailedView() string {
+	cursor := func(field ScriptField) string {
+		if m.field == field {
+			return "> "
+		}
+		return "  "
+	}
+
+	restart := "[ ]"
+	if m.restart {
+		restart = "[x]"
+	}
+
+	// Loader-specific entrypoint, so it's clear what the scripts will call
+	entrypoint := "server.jar"
+	if osmiumConf, err := config.ReadConfig(); err == nil {
+		switch osmiumConf.Loader {
+		case "Forge", "NeoForge":
+			entrypoint = "run.sh / run.bat, JVM flags go to " + util.UserJVMArgsFile
+		case "Quilt":
+			entrypoint = "server/server.jar"
+		}
+	}
+
+	content := ""
+	content += fmt.Sprintf("%sMemory:           %s\n", cursor(FieldMemory), m.memoryInput.View())
+	content += fmt.Sprintf("%sJVM profile:      < %s >\n", cursor(FieldProfile), util.JVMProfiles[m.profile])
+	content += fmt.Sprintf("%sJava path:        %s\n", cursor(FieldJavaPath), m.javaInput.View())
+	content += fmt.Sprintf("%sRestart on crash: %s\n", cursor(FieldRestart), restart)
+	content += fmt.Sprintf("\n  Entrypoint:       %s\n\n", entrypoint)
+	content += fmt.Sprintf("%s[ Generate %s and %s ]\n", cursor(FieldGenerate), util.RunScriptSh, util.RunScriptBat)
+
+	content += "\n\n" + "Navigate using arrow keys, 'left'/'right' to change. Press 'enter' to continue, 'ctrl+backspace' to go back.\n\n"
+	return content
+}
+
 // additional methods
 func (m GenRunScriptModel) Title() string {
 	return "Generate Run Script"
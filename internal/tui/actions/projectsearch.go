@@ -0,0 +1,323 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/limelamp/osmium/internal/shared"
+	"github.com/limelamp/osmium/internal/tui/config"
+	"github.com/limelamp/osmium/internal/tui/constants"
+	"github.com/limelamp/osmium/internal/tui/core"
+	"github.com/limelamp/osmium/internal/tui/styles"
+)
+
+type SearchStep int // Steps of the search action
+const (
+	SearchStepQuery SearchStep = iota
+	SearchStepResults
+	SearchStepInstalling
+)
+
+const searchLimit = 20
+
+// searchResultsMsg carries the hits of a finished search
+type searchResultsMsg struct {
+	results []shared.SearchResult
+	err     error
+}
+
+// searchInstalledMsg is sent once the selected projects are installed
+type searchInstalledMsg struct {
+	installed []string
+	err       error
+}
+
+// ProjectSearch Model
+type ProjectSearchModel struct {
+	layout  core.Layout
+	isFocus bool
+
+	folder     string // "mods" or "plugins", picked from the server's loader
+	source     string
+	step       SearchStep
+	cursor     int
+	queryInput textinput.Model
+	results    []shared.SearchResult
+	selected   map[string]bool // by project ID
+	status     string
+	err        error
+}
+
+func NewProjectSearchModel() ProjectSearchModel {
+	ti := textinput.New()
+	ti.Placeholder = "Search projects..."
+	ti.Focus()
+	ti.CharLimit = 100
+	ti.SetWidth(40)
+
+	m := ProjectSearchModel{
+		folder:     "mods",
+		source:     shared.SourceModrinth,
+		queryInput: ti,
+		selected:   make(map[string]bool),
+	}
+
+	osmiumConf, err := config.ReadConfig()
+	if err != nil {
+		m.err = fmt.Errorf("failed to read osmium.json: %w", err)
+		return m
+	}
+	if slices.Contains(constants.PLUGIN_LOADERS, strings.ToLower(osmiumConf.Loader)) {
+		m.folder = "plugins"
+	}
+
+	return m
+}
+
+// search runs the query in the background
+func search(source, query string) tea.Cmd {
+	return func() tea.Msg {
+		results, err := shared.SearchProjects(source, query, searchLimit)
+		return searchResultsMsg{results: results, err: err}
+	}
+}
+
+// install adds the projects one after another, they all write osmium.json
+func install(projects []shared.SearchResult, source, folder string) tea.Cmd {
+	return func() tea.Msg {
+		var installed []string
+		var errs []error
+		for _, project := range projects {
+			if err := shared.AddProject(source, project.ProjectID, folder); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", project.Slug, err))
+				continue
+			}
+			installed = append(installed, project.Slug)
+		}
+		return searchInstalledMsg{installed: installed, err: errors.Join(errs...)}
+	}
+}
+
+// ProjectSearch State
+func (m ProjectSearchModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m ProjectSearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case searchResultsMsg:
+		m.err = msg.err
+		m.results = msg.results
+		m.cursor = 0
+		m.selected = make(map[string]bool)
+		m.status = ""
+		if msg.err == nil {
+			m.step = SearchStepResults
+			m.queryInput.Blur()
+			if len(msg.results) == 0 {
+				m.status = "No compatible projects found."
+			}
+		}
+		return m, nil
+
+	case searchInstalledMsg:
+		m.err = msg.err
+		m.step = SearchStepResults
+		m.selected = make(map[string]bool)
+		m.status = ""
+		if len(msg.installed) > 0 {
+			m.status = "✓ Installed " + strings.Join(msg.installed, ", ")
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch m.step {
+		case SearchStepQuery:
+			return m.updateQuery(msg)
+		case SearchStepResults:
+			return m.updateResults(msg)
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+func (m ProjectSearchModel) updateQuery(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		if strings.TrimSpace(m.queryInput.Value()) == "" {
+			return m, nil
+		}
+		m.err = nil
+		m.status = "Searching..."
+		return m, search(m.source, m.queryInput.Value())
+	case "ctrl+s":
+		// Cycle through the sources, the results of the old one can't be installed from the new one
+		sources := shared.SourceNames()
+		m.source = sources[(slices.Index(sources, m.source)+1)%len(sources)]
+		m.results = nil
+		m.status = ""
+		return m, nil
+	case "ctrl+h": // ctrl+backspace
+		if len(m.results) > 0 {
+			m.step = SearchStepResults
+			m.queryInput.Blur()
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.queryInput, cmd = m.queryInput.Update(msg)
+	return m, cmd
+}
+
+func (m ProjectSearchModel) updateResults(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down":
+		if m.cursor < len(m.results)-1 {
+			m.cursor++
+		}
+	case "space":
+		if len(m.results) == 0 {
+			return m, nil
+		}
+		id := m.results[m.cursor].ProjectID
+		if m.selected[id] {
+			delete(m.selected, id)
+		} else {
+			m.selected[id] = true
+		}
+	case "enter":
+		if len(m.results) == 0 {
+			return m, nil
+		}
+
+		// Install the selection, or the project under the cursor when nothing is selected
+		var projects []shared.SearchResult
+		for _, result := range m.results {
+			if m.selected[result.ProjectID] {
+				projects = append(projects, result)
+			}
+		}
+		if len(projects) == 0 {
+			projects = append(projects, m.results[m.cursor])
+		}
+
+		m.err = nil
+		m.step = SearchStepInstalling
+		m.status = fmt.Sprintf("Installing %d project(s)...", len(projects))
+		return m, install(projects, m.source, m.folder)
+	case "/", "ctrl+h": // ctrl+backspace
+		m.step = SearchStepQuery
+		m.status = ""
+		return m, m.queryInput.Focus()
+	}
+
+	return m, nil
+}
+
+// formatDownloads shortens a download count, e.g. 1234567 to 1.2M
+func formatDownloads(downloads int) string {
+	switch {
+	case downloads >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(downloads)/1_000_000)
+	case downloads >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(downloads)/1_000)
+	default:
+		return fmt.Sprintf("%d", downloads)
+	}
+}
+
+// truncate cuts s to width characters
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width <= 1 || len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// ProjectSearch View
+func (m ProjectSearchModel) View() tea.View {
+	content := ""
+
+	if m.err != nil {
+		errorStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF0000")).
+			Bold(true)
+		content += errorStyle.Render("Error: "+m.err.Error()) + "\n\n"
+	}
+
+	content += fmt.Sprintf("Searching %s on %s for this server\n\n", m.folder, m.source)
+	content += m.queryInput.View() + "\n\n"
+
+	if m.status != "" {
+		content += m.status + "\n\n"
+	}
+
+	if m.step != SearchStepQuery && len(m.results) > 0 {
+		// Every result takes three lines, only show the ones around the cursor
+		visible := max((m.layout.Height-14)/3, 1)
+		start := min(max(m.cursor-visible/2, 0), max(len(m.results)-visible, 0))
+		end := min(start+visible, len(m.results))
+		width := max(m.layout.Width-12, 20)
+
+		for i := start; i < end; i++ {
+			result := m.results[i]
+
+			cursor := "  "
+			if m.cursor == i {
+				cursor = "> "
+			}
+			selected := "[ ]"
+			if m.selected[result.ProjectID] {
+				selected = "[x]"
+			}
+
+			content += fmt.Sprintf("%s%s %s (%s)  ↓%s\n", cursor, selected, result.Title, result.Slug, formatDownloads(result.Downloads))
+			content += "      " + truncate(result.Description, width) + "\n"
+			content += "      " + truncate(strings.Join(result.Categories, ", "), width) + "\n"
+		}
+	}
+
+	switch m.step {
+	case SearchStepQuery:
+		content += "\n\n" + "Press 'enter' to search, 'ctrl+s' to switch source, 'ctrl+backspace' to go back to the results.\n\n"
+	case SearchStepResults:
+		content += "\n\n" + "Press 'space' to select, 'enter' to install, '/' to search again.\n\n"
+	}
+
+	return tea.NewView(styles.Container(
+		m.layout.Width,
+		m.layout.Height,
+		m.isFocus,
+		m.Title(),
+		content,
+		false,
+	))
+}
+
+// additional methods
+func (m ProjectSearchModel) Title() string {
+	return "Search Projects"
+}
+
+func (m ProjectSearchModel) SetLayout(l core.Layout) core.Action {
+	m.layout = l
+	return m
+}
+
+func (m ProjectSearchModel) SetFocus(focused bool) core.Action {
+	m.isFocus = focused
+	return m
+}
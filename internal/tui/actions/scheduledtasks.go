@@ -0,0 +1,351 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/limelamp/osmium/internal/shared"
+	"github.com/limelamp/osmium/internal/tui/core"
+	"github.com/limelamp/osmium/internal/tui/storage"
+	"github.com/limelamp/osmium/internal/tui/styles"
+)
+
+type TaskStep int // Steps of the "add task" and "edit task" form
+const (
+	TaskStepList TaskStep = iota
+	TaskStepCron
+	TaskStepAction
+	TaskStepArgument
+)
+
+// ScheduledTasks Model
+type ScheduledTasksModel struct {
+	layout  core.Layout
+	isFocus bool
+
+	store        *storage.ServerStore
+	server       storage.Server
+	step         TaskStep
+	cursor       int
+	actionCursor int
+	cronInput    textinput.Model
+	argInput     textinput.Model
+	editingID    string // task being edited, empty when adding one
+	err          error
+}
+
+func NewScheduledTasksModel() ScheduledTasksModel {
+	cron := textinput.New()
+	cron.Placeholder = "0 4 * * *"
+	cron.CharLimit = 100
+	cron.SetWidth(30)
+
+	arg := textinput.New()
+	arg.Placeholder = "say Restarting soon"
+	arg.CharLimit = 500
+	arg.SetWidth(40)
+
+	m := ScheduledTasksModel{
+		store:     storage.NewServerStore(),
+		cronInput: cron,
+		argInput:  arg,
+	}
+	m.reload()
+
+	return m
+}
+
+// reload reads the server of the current directory from the store
+func (m *ScheduledTasksModel) reload() {
+	dir, err := os.Getwd()
+	if err != nil {
+		m.err = err
+		return
+	}
+
+	server, err := m.store.FindByPath(dir)
+	if err != nil {
+		m.err = err
+		return
+	}
+
+	m.server = server
+	if m.cursor >= len(server.Tasks) {
+		m.cursor = max(len(server.Tasks)-1, 0)
+	}
+}
+
+// ScheduledTasks State
+func (m ScheduledTasksModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m ScheduledTasksModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.step == TaskStepList {
+			return m.updateList(msg)
+		}
+		return m.updateForm(msg)
+	}
+
+	return m, nil
+}
+
+func (m ScheduledTasksModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down":
+		if m.cursor < len(m.server.Tasks)-1 {
+			m.cursor++
+		}
+	case "a":
+		if m.server.ID == "" {
+			return m, nil
+		}
+		m.err = nil
+		m.step = TaskStepCron
+		m.editingID = ""
+		m.cronInput.Reset()
+		m.argInput.Reset()
+		m.actionCursor = 0
+		return m, m.cronInput.Focus()
+	case "e", "enter":
+		if len(m.server.Tasks) == 0 {
+			return m, nil
+		}
+		task := m.server.Tasks[m.cursor]
+		m.err = nil
+		m.step = TaskStepCron
+		m.editingID = task.ID
+		m.cronInput.SetValue(task.Cron)
+		m.argInput.SetValue(task.Argument)
+		m.actionCursor = 0
+		for i, action := range storage.TaskActions {
+			if action == task.Action {
+				m.actionCursor = i
+			}
+		}
+		return m, m.cronInput.Focus()
+	case "d", "delete":
+		if len(m.server.Tasks) == 0 {
+			return m, nil
+		}
+		id := m.server.Tasks[m.cursor].ID
+		m.err = m.store.Update(m.server.ID, func(s *storage.Server) error {
+			for i := range s.Tasks {
+				if s.Tasks[i].ID == id {
+					s.Tasks = append(s.Tasks[:i], s.Tasks[i+1:]...)
+					break
+				}
+			}
+			return nil
+		})
+		m.reload()
+	case "space":
+		if len(m.server.Tasks) == 0 {
+			return m, nil
+		}
+		id := m.server.Tasks[m.cursor].ID
+		m.err = m.store.Update(m.server.ID, func(s *storage.Server) error {
+			for i := range s.Tasks {
+				if s.Tasks[i].ID == id {
+					s.Tasks[i].Enabled = !s.Tasks[i].Enabled
+					s.Tasks[i].NextRun = shared.NextTaskRun(s.Tasks[i], time.Now())
+					break
+				}
+			}
+			return nil
+		})
+		m.reload()
+	case "r":
+		m.err = nil
+		m.reload()
+	}
+
+	return m, nil
+}
+
+func (m ScheduledTasksModel) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+h": // ctrl+backspace
+		m.step = TaskStepList
+		m.editingID = ""
+		m.cronInput.Blur()
+		m.argInput.Blur()
+		return m, nil
+	case "up":
+		if m.step == TaskStepAction && m.actionCursor > 0 {
+			m.actionCursor--
+		}
+		return m, nil
+	case "down":
+		if m.step == TaskStepAction && m.actionCursor < len(storage.TaskActions)-1 {
+			m.actionCursor++
+		}
+		return m, nil
+	case "enter":
+		switch m.step {
+		case TaskStepCron:
+			if _, err := shared.ParseCron(m.cronInput.Value()); err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.err = nil
+			m.cronInput.Blur()
+			m.step = TaskStepAction
+			return m, nil
+		case TaskStepAction:
+			m.step = TaskStepArgument
+			return m, m.argInput.Focus()
+		case TaskStepArgument:
+			task := storage.ScheduledTask{
+				ID:       shared.NewTaskID(),
+				Cron:     m.cronInput.Value(),
+				Action:   storage.TaskActions[m.actionCursor],
+				Argument: m.argInput.Value(),
+				Enabled:  true,
+			}
+			if err := shared.ValidateTask(task); err != nil {
+				m.err = err
+				return m, nil
+			}
+			task.NextRun = shared.NextTaskRun(task, time.Now())
+
+			if m.editingID != "" {
+				// Edits keep the task's ID, whether it's enabled and when it last ran
+				m.err = shared.UpdateTask(m.store, m.server.ID, m.editingID, func(t *storage.ScheduledTask) {
+					t.Cron, t.Action, t.Argument = task.Cron, task.Action, task.Argument
+					t.NextRun = task.NextRun
+				})
+			} else {
+				m.err = m.store.Update(m.server.ID, func(s *storage.Server) error {
+					s.Tasks = append(s.Tasks, task)
+					return nil
+				})
+			}
+			m.editingID = ""
+			m.argInput.Blur()
+			m.step = TaskStepList
+			m.reload()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	switch m.step {
+	case TaskStepCron:
+		m.cronInput, cmd = m.cronInput.Update(msg)
+	case TaskStepArgument:
+		m.argInput, cmd = m.argInput.Update(msg)
+	}
+	return m, cmd
+}
+
+// ScheduledTasks View
+func (m ScheduledTasksModel) View() tea.View {
+	content := ""
+
+	if m.err != nil {
+		errorStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF0000")).
+			Bold(true)
+		content += errorStyle.Render("Error: "+m.err.Error()) + "\n\n"
+	}
+
+	switch m.step {
+	case TaskStepList:
+		if m.server.ID == "" {
+			content += "This directory isn't a registered server.\n"
+			break
+		}
+
+		if len(m.server.Tasks) == 0 {
+			content += "No scheduled tasks yet.\n"
+		}
+
+		for i, task := range m.server.Tasks {
+			cursor := "  "
+			if m.cursor == i {
+				cursor = "> "
+			}
+
+			enabled := "[x]"
+			if !task.Enabled {
+				enabled = "[ ]"
+			}
+
+			nextRun := "-"
+			if task.Enabled && !task.NextRun.IsZero() {
+				nextRun = task.NextRun.Format("01-02 15:04")
+			}
+			lastRun := "never"
+			if !task.LastRun.IsZero() {
+				lastRun = fmt.Sprintf("%s (%s)", task.LastRun.Format("01-02 15:04"), task.LastResult)
+			}
+
+			content += fmt.Sprintf("%s%s %-12s %-8s %s\n", cursor, enabled, task.Cron, task.Action, task.Argument)
+			content += fmt.Sprintf("      last: %s  next: %s\n", lastRun, nextRun)
+		}
+
+		if len(m.server.Tasks) > 0 {
+			warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500"))
+			content += "\n" + warnStyle.Render("⚠ Tasks only run while the server is started with 'osmium run'. Servers started from here don't run them, use 'osmium schedule run' next to them.") + "\n"
+		}
+
+		content += "\n\n" + "Press 'a' to add, 'e' or 'enter' to edit, 'd' to delete, 'space' to toggle, 'r' to refresh.\n\n"
+	case TaskStepCron:
+		if m.editingID != "" {
+			content += fmt.Sprintf("Editing %s\n\n", m.editingID)
+		}
+		content += "Cron expression (minute hour day month weekday):\n\n"
+		content += m.cronInput.View() + "\n"
+	case TaskStepAction:
+		content += "Action:\n\n"
+		for i, action := range storage.TaskActions {
+			cursor := "  "
+			if m.actionCursor == i {
+				cursor = "> "
+			}
+			content += fmt.Sprintf("%s %s\n", cursor, action)
+		}
+	case TaskStepArgument:
+		content += fmt.Sprintf("Argument for %s (console command, or \"incremental\" for backups):\n\n", storage.TaskActions[m.actionCursor])
+		content += m.argInput.View() + "\n"
+	}
+
+	if m.step != TaskStepList {
+		content += "\n\n" + "Press 'enter' to continue, 'ctrl+backspace' to cancel.\n\n"
+	}
+
+	return tea.NewView(styles.Container(
+		m.layout.Width,
+		m.layout.Height,
+		m.isFocus,
+		m.Title(),
+		content,
+		false,
+	))
+}
+
+// additional methods
+func (m ScheduledTasksModel) Title() string {
+	return "Scheduled Tasks"
+}
+
+func (m ScheduledTasksModel) SetLayout(l core.Layout) core.Action {
+	m.layout = l
+	return m
+}
+
+func (m ScheduledTasksModel) SetFocus(focused bool) core.Action {
+	m.isFocus = focused
+	return m
+}
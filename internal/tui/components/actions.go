@@ -20,7 +20,7 @@ type ActionsModel struct {
 func NewActionsModel() ActionsModel {
 	return ActionsModel{
 		cursor:  0,
-		options: []string{"Remove files", "Generate Run Script", "Manage Configs", "Mod Management", "Plugin Management"},
+		options: []string{"Remove files", "Generate Run Script", "Manage Configs", "Mod Management", "Plugin Management", "Scheduled Tasks", "Search Projects"},
 	}
 }
 
@@ -76,6 +76,18 @@ func (m ActionsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						NewAction: actions.NewPluginManagementModel(),
 					}
 				}
+			case 5:
+				return m, func() tea.Msg {
+					return core.SwitchActionMsg{
+						NewAction: actions.NewScheduledTasksModel(),
+					}
+				}
+			case 6:
+				return m, func() tea.Msg {
+					return core.SwitchActionMsg{
+						NewAction: actions.NewProjectSearchModel(),
+					}
+				}
 			}
 
 		}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"os"
 	"os/exec"
 	"strings"
 
@@ -15,7 +14,6 @@ import (
 	"github.com/limelamp/osmium/internal/tui/config"
 	"github.com/limelamp/osmium/internal/tui/core"
 	"github.com/limelamp/osmium/internal/tui/styles"
-	"github.com/limelamp/osmium/internal/util"
 )
 
 // RunServer Model
@@ -79,15 +77,13 @@ func (m ActivityModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
-		javaPath, args := util.GetServerRunCommand(osmiumConf.Loader)
-
-		m.javaCMD = exec.Command(javaPath, args...)
-
-		m.javaCMD.Dir, _ = os.Getwd()
-
 		// Point both outputs to our buffer
-		m.javaCMD.Stdout = m.output
-		m.javaCMD.Stderr = m.output
+		m.javaCMD, err = shared.NewServerCommand(osmiumConf.Loader, m.output)
+		if err != nil {
+			m.err = err
+			m.firstRun = false
+			return m, nil
+		}
 
 		m.inputPipe, err = m.javaCMD.StdinPipe() // This is the "entrance"
 		if err != nil {
@@ -10,7 +10,8 @@ const ConfigFileName = "config.json"
 
 // AppConfig stores global application settings.
 type AppConfig struct {
-	Theme string `json:"theme"`
+	Theme            string `json:"theme"`
+	CurseForgeAPIKey string `json:"curseforge_api_key,omitempty"`
 }
 
 // DefaultConfig returns the fallback configuration.
@@ -56,5 +57,5 @@ func SaveConfig(cfg AppConfig) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filePath, data, 0644)
+	return os.WriteFile(filePath, data, 0600) // may hold API keys
 }
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const LockFileName = "osmium.lock.json"
+
+// LockedProject pins the exact version of a tracked project
+type LockedProject struct {
+	Source        string   `json:"source,omitempty"` // empty means Modrinth
+	ProjectID     string   `json:"project_id"`
+	VersionID     string   `json:"version_id"`
+	VersionNumber string   `json:"version_number"`
+	FileName      string   `json:"filename"`
+	DownloadURL   string   `json:"url"`
+	ManualURL     string   `json:"manual_url,omitempty"`
+	Origin        string   `json:"origin,omitempty"`
+	SHA1          string   `json:"sha1"`
+	SHA256        string   `json:"sha256,omitempty"`
+	SHA512        string   `json:"sha512,omitempty"`
+	Dependencies  []string `json:"dependencies,omitempty"` // project IDs of required dependencies
+}
+
+// LockFile records the resolved set of projects of a server, so every install of it is identical
+type LockFile struct {
+	LockVersion int                      `json:"lock_version"`
+	Loader      string                   `json:"loader"`
+	Version     string                   `json:"version"`
+	Mods        map[string]LockedProject `json:"mods"`
+	Plugins     map[string]LockedProject `json:"plugins"`
+}
+
+func WriteLock(lock *LockFile) error {
+	bytes, err := json.MarshalIndent(lock, "", " ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lock file: %w", err)
+	}
+
+	if err := os.WriteFile(LockFileName, bytes, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", LockFileName, err)
+	}
+
+	return nil
+}
+
+func ReadLock() (*LockFile, error) {
+	data, err := os.ReadFile(LockFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock LockFile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", LockFileName, err)
+	}
+	if lock.Mods == nil {
+		lock.Mods = make(map[string]LockedProject)
+	}
+	if lock.Plugins == nil {
+		lock.Plugins = make(map[string]LockedProject)
+	}
+
+	return &lock, nil
+}
@@ -7,10 +7,16 @@ import (
 )
 
 type Project struct {
-	VersionNumber string `json:"version_number"`
-	FileName      string `json:"filename"` // files[0].filename
-	DownloadURL   string `json:"url"`      // files[0].download_url
-	SHA1          string `json:"sha1"`
+	VersionNumber string   `json:"version_number"`
+	FileName      string   `json:"filename"` // files[0].filename
+	DownloadURL   string   `json:"url"`      // files[0].download_url
+	SHA1          string   `json:"sha1"`
+	Source        string   `json:"source,omitempty"`        // where the project came from, empty means Modrinth
+	ManualURL     string   `json:"manual_url,omitempty"`    // set instead of url when the file has to be downloaded by hand
+	Origin        string   `json:"origin,omitempty"`        // the local file a project was added from, relative to the server
+	Repo          string   `json:"repo,omitempty"`          // owner/repo whose GitHub releases update the project
+	Asset         string   `json:"asset,omitempty"`         // glob matching the project's file in those releases
+	DependencyOf  []string `json:"dependency_of,omitempty"` // projects that pulled this one in, empty when it was added directly
 }
 
 type OsmiumConfig struct {
@@ -1,11 +1,19 @@
 package constants
 
+// Server categories, as recorded in osmium.json
+const (
+	CategoryVanilla   = "Vanilla/Simple"
+	CategoryPlugin    = "Plugin-Based"
+	CategoryModLoader = "Mod Loaders"
+	CategoryHybrid    = "Hybrid"
+)
+
 // Category to server types mapping
 var CategoryOptions = map[string][]string{
-	"Vanilla/Simple": {"Vanilla"},
-	"Plugin-Based":   {"Paper", "Purpur"},
-	"Mod Loaders":    {"Fabric", "NeoForge", "Forge", "Quilt"},
-	"Hybrid":         {"Youer"},
+	CategoryVanilla:   {"Vanilla"},
+	CategoryPlugin:    {"Paper", "Purpur"},
+	CategoryModLoader: {"Fabric", "NeoForge", "Forge", "Quilt"},
+	CategoryHybrid:    {"Youer"},
 }
 
 var MOD_LOADERS = []string{"fabric", "forge", "neoforge", "quilt", "liteloader", "modloader", "rift"}
@@ -2,23 +2,51 @@ package storage
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/limelamp/osmium/internal/tui/config"
 )
 
 const ServersFileName = "servers.json"
 
+// Scheduled task actions
+const (
+	TaskCommand = "command" // send Argument to the server console
+	TaskRestart = "restart" // gracefully restart the server
+	TaskBackup  = "backup"  // create a world backup, Argument "incremental" uses the backup store
+	TaskUpdate  = "update"  // update all tracked mods/plugins
+)
+
+var TaskActions = []string{TaskCommand, TaskRestart, TaskBackup, TaskUpdate}
+
+// ScheduledTask is a cron-style job that runs while the server is supervised by Osmium.
+type ScheduledTask struct {
+	ID         string    `json:"id"`
+	Cron       string    `json:"cron"`   // e.g. "0 4 * * *"
+	Action     string    `json:"action"` // one of TaskActions
+	Argument   string    `json:"argument,omitempty"`
+	Enabled    bool      `json:"enabled"`
+	LastRun    time.Time `json:"last_run,omitzero"`
+	LastResult string    `json:"last_result,omitempty"`
+	NextRun    time.Time `json:"next_run,omitzero"`
+}
+
 // Server represents a managed Minecraft server instance.
 type Server struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Path    string `json:"path"`    // Location of the actual Minecraft server files
-	Version string `json:"version"` // e.g., "1.20.4"
-	Type    string `json:"type"`    // vanilla, paper, fabric, etc.
-	Memory  string `json:"memory"`  // e.g., "2G" or "4G"
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Path       string          `json:"path"`                  // Location of the actual Minecraft server files
+	Version    string          `json:"version"`               // e.g., "1.20.4"
+	Type       string          `json:"type"`                  // vanilla, paper, fabric, etc.
+	Memory     string          `json:"memory"`                // e.g., "2G" or "4G"
+	JVMProfile string          `json:"jvm_profile,omitempty"` // aikar, zgc or minimal
+	JVMFlags   []string        `json:"jvm_flags,omitempty"`   // extra flags appended to the profile
+	JavaPath   string          `json:"java_path,omitempty"`   // pinned java binary, empty uses PATH
+	Tasks      []ScheduledTask `json:"tasks,omitempty"`
 }
 
 // ServerStore manages thread-safe JSON interactions for server data.
@@ -45,6 +73,106 @@ func (s *ServerStore) LoadAll() ([]Server, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	return s.load()
+}
+
+// SaveAll serializes and saves the complete servers list.
+func (s *ServerStore) SaveAll(servers []Server) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.save(servers)
+}
+
+// FindByID returns the server with the given ID.
+func (s *ServerStore) FindByID(id string) (Server, error) {
+	servers, err := s.LoadAll()
+	if err != nil {
+		return Server{}, err
+	}
+
+	for _, server := range servers {
+		if server.ID == id {
+			return server, nil
+		}
+	}
+
+	return Server{}, fmt.Errorf("server %s not found", id)
+}
+
+// FindByPath returns the server whose files live in dir.
+func (s *ServerStore) FindByPath(dir string) (Server, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return Server{}, err
+	}
+
+	servers, err := s.LoadAll()
+	if err != nil {
+		return Server{}, err
+	}
+
+	for _, server := range servers {
+		if absPath, err := filepath.Abs(server.Path); err == nil && absPath == absDir {
+			return server, nil
+		}
+	}
+
+	return Server{}, fmt.Errorf("no server registered for %s", absDir)
+}
+
+// Add registers a new server. It fails if the ID is taken or the path is already registered.
+func (s *ServerStore) Add(server Server) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	servers, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	absPath, err := filepath.Abs(server.Path)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range servers {
+		if existing.ID == server.ID {
+			return fmt.Errorf("server %s already exists", server.ID)
+		}
+		if existingPath, err := filepath.Abs(existing.Path); err == nil && existingPath == absPath {
+			return fmt.Errorf("%s is already registered as %s", absPath, existing.ID)
+		}
+	}
+
+	server.Path = absPath
+	return s.save(append(servers, server))
+}
+
+// Update applies fn to the server with the given ID and saves the result.
+// The whole read-modify-write happens under the store lock.
+func (s *ServerStore) Update(id string, fn func(*Server) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	servers, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	for i := range servers {
+		if servers[i].ID == id {
+			if err := fn(&servers[i]); err != nil {
+				return err
+			}
+			return s.save(servers)
+		}
+	}
+
+	return fmt.Errorf("server %s not found", id)
+}
+
+func (s *ServerStore) load() ([]Server, error) {
 	filePath, err := s.GetFilePath()
 	if err != nil {
 		return nil, err
@@ -73,11 +201,7 @@ func (s *ServerStore) LoadAll() ([]Server, error) {
 	return servers, nil
 }
 
-// SaveAll serializes and saves the complete servers list.
-func (s *ServerStore) SaveAll(servers []Server) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
+func (s *ServerStore) save(servers []Server) error {
 	filePath, err := s.GetFilePath()
 	if err != nil {
 		return err
@@ -0,0 +1,452 @@
+package util
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+)
+
+/*
+	The download cache is shared by every server on the machine. Files are stored by content
+	inside the app dir, once per hash algorithm so a lookup by any published hash finds them:
+
+		cache/sha1/<hash>/<filename>
+		cache/sha256/<hash>/<filename>
+		cache/sha512/<hash>/<filename>
+
+	The three paths are hardlinks of one file, and servers get hardlinks (or copies, across
+	filesystems) of it too, so a file's modification time is when it was last used.
+	Downloads without a published hash (e.g. the Fabric launcher) are only cached under their
+	sha256, with an alias like "server:Fabric:1.21.1" in cache/aliases.json for offline installs.
+*/
+
+const (
+	CacheFolder      = "cache"
+	cacheAliasesFile = "aliases.json"
+)
+
+// cacheAlgorithms are the hashes files are stored under, sha256 is the canonical one
+var cacheAlgorithms = []string{"sha1", "sha256", "sha512"}
+
+// Offline makes downloads come from the cache only, set by the --offline flag
+var Offline bool
+
+// ErrNotCached is returned in offline mode for files that aren't in the cache
+var ErrNotCached = errors.New("not in the download cache")
+
+// CacheEntry is one file in the download cache
+type CacheEntry struct {
+	Name     string
+	SHA256   string
+	Size     int64
+	LastUsed time.Time
+	path     string
+}
+
+// CacheDir returns the folder of the download cache
+func CacheDir() (string, error) {
+	appDir, err := config.GetAppDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(appDir, CacheFolder), nil
+}
+
+// fileDigests returns the sha1, sha256 and sha512 of a file
+func fileDigests(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sha1Hash, sha256Hash, sha512Hash := sha1.New(), sha256.New(), sha512.New()
+	if _, err := io.Copy(io.MultiWriter(sha1Hash, sha256Hash, sha512Hash), file); err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"sha1":   hex.EncodeToString(sha1Hash.Sum(nil)),
+		"sha256": hex.EncodeToString(sha256Hash.Sum(nil)),
+		"sha512": hex.EncodeToString(sha512Hash.Sum(nil)),
+	}, nil
+}
+
+// matchesChecksums reports whether digests agree with every checksum given
+func matchesChecksums(digests map[string]string, sums Checksums) bool {
+	for algo, sum := range map[string]string{"sha1": sums.SHA1, "sha256": sums.SHA256, "sha512": sums.SHA512} {
+		if sum != "" && !strings.EqualFold(digests[algo], sum) {
+			return false
+		}
+	}
+	return true
+}
+
+// entryFile returns the file inside a cache entry folder
+func entryFile(dir string) (string, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && !strings.HasSuffix(entry.Name(), ".part") {
+			return filepath.Join(dir, entry.Name()), true
+		}
+	}
+	return "", false
+}
+
+// lookupCache finds a cached file matching the checksums, verifying its contents.
+// Entries that don't match anymore are dropped.
+func lookupCache(sums Checksums) (string, bool) {
+	cacheDir, err := CacheDir()
+	if err != nil {
+		return "", false
+	}
+
+	for algo, sum := range map[string]string{"sha512": sums.SHA512, "sha256": sums.SHA256, "sha1": sums.SHA1} {
+		if sum == "" {
+			continue
+		}
+
+		dir := filepath.Join(cacheDir, algo, strings.ToLower(sum))
+		path, ok := entryFile(dir)
+		if !ok {
+			continue
+		}
+
+		digests, err := fileDigests(path)
+		if err != nil || !matchesChecksums(digests, sums) {
+			os.RemoveAll(dir) // the entry this hash points at, so the next download can replace it
+			continue
+		}
+		return path, true
+	}
+	return "", false
+}
+
+// removeCacheEntry deletes a file from every algorithm folder of the cache
+func removeCacheEntry(cacheDir string, digests map[string]string) {
+	for _, algo := range cacheAlgorithms {
+		if digests[algo] != "" {
+			os.RemoveAll(filepath.Join(cacheDir, algo, digests[algo]))
+		}
+	}
+}
+
+// linkOrCopy puts src at dst as a hardlink, or a copy when linking isn't possible.
+// dst is replaced, never written through, so a hardlinked file is never modified in place.
+func linkOrCopy(src, dst string) error {
+	return placeFile(src, dst, true)
+}
+
+// placeFile replaces dst with src through a temp file next to it, unique so concurrent installs
+// of the same file don't trip over each other. Hardlinks when link is set and the filesystem allows it.
+func placeFile(src, dst string, link bool) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".*.part")
+	if err != nil {
+		return err
+	}
+	tmp := out.Name()
+
+	linked := false
+	if link {
+		// The temp file only reserved the name, the link takes its place
+		out.Close()
+		os.Remove(tmp)
+		if err := os.Link(src, tmp); err == nil {
+			linked = true
+		} else if out, err = os.OpenFile(tmp, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644); err != nil {
+			return err
+		}
+	}
+
+	if !linked {
+		in, err := os.Open(src)
+		if err != nil {
+			out.Close()
+			os.Remove(tmp)
+			return err
+		}
+		defer in.Close()
+
+		_, err = io.Copy(out, in)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Chmod(tmp, 0644)
+		}
+		if err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// storeInCache adds a verified download to the cache and returns its sha256.
+// The cache is best effort, a failure only means the next install downloads again.
+func storeInCache(path string) (string, error) {
+	cacheDir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	digests, err := fileDigests(path)
+	if err != nil {
+		return "", err
+	}
+
+	// The canonical copy first, the other algorithms link to it
+	canonical := filepath.Join(cacheDir, "sha256", digests["sha256"], filepath.Base(path))
+	if existing, ok := entryFile(filepath.Dir(canonical)); ok {
+		canonical = existing
+	} else if err := linkOrCopy(path, canonical); err != nil {
+		return "", err
+	}
+
+	for _, algo := range []string{"sha1", "sha512"} {
+		dir := filepath.Join(cacheDir, algo, digests[algo])
+		if _, ok := entryFile(dir); ok {
+			continue
+		}
+		if err := linkOrCopy(canonical, filepath.Join(dir, filepath.Base(canonical))); err != nil {
+			return "", err
+		}
+	}
+
+	return digests["sha256"], nil
+}
+
+// touch marks a cache entry as used now
+func touch(path string) {
+	now := time.Now()
+	os.Chtimes(path, now, now)
+}
+
+// DownloadCached is DownloadVerified backed by the download cache: files with a published hash
+// are linked from the cache when they're in it, and added to it after downloading.
+// In offline mode nothing is downloaded and files that aren't cached fail with ErrNotCached,
+// as do files without a URL.
+func DownloadCached(url, path string, sums Checksums) error {
+	hasHash := sums.SHA1 != "" || sums.SHA256 != "" || sums.SHA512 != ""
+
+	if hasHash {
+		if cached, ok := lookupCache(sums); ok {
+			touch(cached)
+			return linkOrCopy(cached, path)
+		}
+	}
+
+	if Offline {
+		return fmt.Errorf("%s is %w (offline mode)", filepath.Base(path), ErrNotCached)
+	}
+	if url == "" {
+		return fmt.Errorf("%s is %w and has no download URL", filepath.Base(path), ErrNotCached)
+	}
+
+	if err := DownloadVerified(url, path, sums); err != nil {
+		return err
+	}
+
+	if _, err := storeInCache(path); err != nil {
+		fmt.Printf("⚠ Couldn't add %s to the download cache: %v\n", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// CacheFile adds a local file to the download cache and returns its hashes, so files that
+// can't be downloaded (e.g. jars added from disk) can be installed again by hash.
+// The file is copied, never linked: it lives outside the cache and may be rebuilt in place.
+func CacheFile(path string) (Checksums, error) {
+	cacheDir, err := CacheDir()
+	if err != nil {
+		return Checksums{}, err
+	}
+
+	// A private copy is hashed and stored, so the entry matches its hash even if the file changes meanwhile
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return Checksums{}, err
+	}
+	incoming, err := os.MkdirTemp(cacheDir, ".incoming-")
+	if err != nil {
+		return Checksums{}, err
+	}
+	defer os.RemoveAll(incoming)
+
+	copied := filepath.Join(incoming, filepath.Base(path))
+	if err := placeFile(path, copied, false); err != nil {
+		return Checksums{}, err
+	}
+
+	digests, err := fileDigests(copied)
+	if err != nil {
+		return Checksums{}, err
+	}
+	if _, err := storeInCache(copied); err != nil {
+		return Checksums{}, err
+	}
+	return Checksums{SHA1: digests["sha1"], SHA256: digests["sha256"], SHA512: digests["sha512"]}, nil
+}
+
+// readCacheAliases reads the alias index of the cache
+func readCacheAliases() map[string]string {
+	aliases := make(map[string]string)
+	cacheDir, err := CacheDir()
+	if err != nil {
+		return aliases
+	}
+
+	data, err := os.ReadFile(filepath.Join(cacheDir, cacheAliasesFile))
+	if err != nil {
+		return aliases
+	}
+	json.Unmarshal(data, &aliases)
+	return aliases
+}
+
+// SetCacheAlias points a name like "server:Fabric:1.21.1" at a cached file, so it can be found offline
+func SetCacheAlias(alias, path string) error {
+	sha256Sum, err := storeInCache(path)
+	if err != nil {
+		return err
+	}
+
+	cacheDir, err := CacheDir()
+	if err != nil {
+		return err
+	}
+
+	aliases := readCacheAliases()
+	aliases[alias] = sha256Sum
+	data, err := json.MarshalIndent(aliases, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(cacheDir, cacheAliasesFile+".part")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(cacheDir, cacheAliasesFile))
+}
+
+// LinkCacheAlias puts the file an alias points at to path, reporting whether it was cached
+func LinkCacheAlias(alias, path string) (bool, error) {
+	sha256Sum, ok := readCacheAliases()[alias]
+	if !ok {
+		return false, nil
+	}
+
+	cached, ok := lookupCache(Checksums{SHA256: sha256Sum})
+	if !ok {
+		return false, nil
+	}
+
+	touch(cached)
+	return true, linkOrCopy(cached, path)
+}
+
+// ListCache returns the files in the download cache, most recently used first
+func ListCache() ([]CacheEntry, error) {
+	cacheDir, err := CacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	dirs, err := os.ReadDir(filepath.Join(cacheDir, "sha256"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the download cache: %w", err)
+	}
+
+	var entries []CacheEntry
+	for _, dir := range dirs {
+		path, ok := entryFile(filepath.Join(cacheDir, "sha256", dir.Name()))
+		if !ok {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, CacheEntry{
+			Name:     filepath.Base(path),
+			SHA256:   dir.Name(),
+			Size:     info.Size(),
+			LastUsed: info.ModTime(),
+			path:     path,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// PruneCache removes files not used within olderThan (all files when it's zero),
+// returning how many were removed and how many bytes that freed
+func PruneCache(olderThan time.Duration) (int, int64, error) {
+	entries, err := ListCache()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	cacheDir, err := CacheDir()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	removed, freed := 0, int64(0)
+	cutoff := time.Now().Add(-olderThan)
+	for _, entry := range entries {
+		if olderThan > 0 && entry.LastUsed.After(cutoff) {
+			continue
+		}
+
+		digests, err := fileDigests(entry.path)
+		if err != nil {
+			digests = map[string]string{"sha256": entry.SHA256}
+		}
+		removeCacheEntry(cacheDir, digests)
+		removed++
+		freed += entry.Size
+	}
+
+	// Aliases of removed files are dropped too
+	aliases := readCacheAliases()
+	for alias, sha256Sum := range aliases {
+		if _, ok := entryFile(filepath.Join(cacheDir, "sha256", sha256Sum)); !ok {
+			delete(aliases, alias)
+		}
+	}
+	if data, err := json.MarshalIndent(aliases, "", "  "); err == nil {
+		os.WriteFile(filepath.Join(cacheDir, cacheAliasesFile), data, 0644)
+	}
+
+	return removed, freed, nil
+}
@@ -0,0 +1,102 @@
+package util
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Checksums are the expected hex digests of a download, empty ones aren't checked
+type Checksums struct {
+	SHA1   string
+	SHA256 string
+	SHA512 string
+}
+
+// ErrChecksumMismatch is returned when a downloaded file doesn't match its expected hash
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// downloadHTTPClient has a longer timeout than the API clients, server jars and JDKs are large
+var downloadHTTPClient = &http.Client{Timeout: 10 * time.Minute}
+
+// DownloadVerified downloads url to path through a temporary file in the same folder,
+// checks it against every checksum given and only then renames it into place.
+// On any failure the temporary file is removed and path is left untouched.
+func DownloadVerified(url, path string, sums Checksums) error {
+	resp, err := downloadHTTPClient.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", filepath.Base(path), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", filepath.Base(path), resp.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s folder: %w", filepath.Dir(path), err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.part")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	// CreateTemp makes the file private, downloads get the usual permissions
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	hashers := map[string]hash.Hash{}
+	expected := map[string]string{}
+	for name, sum := range map[string]string{"sha1": sums.SHA1, "sha256": sums.SHA256, "sha512": sums.SHA512} {
+		if sum == "" {
+			continue
+		}
+		switch name {
+		case "sha1":
+			hashers[name] = sha1.New()
+		case "sha256":
+			hashers[name] = sha256.New()
+		case "sha512":
+			hashers[name] = sha512.New()
+		}
+		expected[name] = sum
+	}
+
+	writers := []io.Writer{tmp}
+	for _, hasher := range hashers {
+		writers = append(writers, hasher)
+	}
+
+	_, err = io.Copy(io.MultiWriter(writers...), resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", filepath.Base(path), err)
+	}
+
+	for name, hasher := range hashers {
+		if actual := hex.EncodeToString(hasher.Sum(nil)); !strings.EqualFold(actual, expected[name]) {
+			return fmt.Errorf("%w for %s: expected %s %s, got %s", ErrChecksumMismatch, filepath.Base(path), name, expected[name], actual)
+		}
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to move %s into place: %w", filepath.Base(path), err)
+	}
+	return nil
+}
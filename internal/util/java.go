@@ -0,0 +1,243 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// JavaRuntime is a java binary found on this machine
+type JavaRuntime struct {
+	Path    string // absolute path of the java binary
+	Version string // e.g. "21.0.2" or "1.8.0_392"
+	Major   int    // e.g. 21 or 8
+	Source  string // where it was found: PATH, JAVA_HOME, system, sdkman, ...
+}
+
+var javaVersionPattern = regexp.MustCompile(`version "([^"]+)"`)
+
+// ParseJavaVersion reads the version string and major version from `java -version` output
+func ParseJavaVersion(output string) (string, int, error) {
+	match := javaVersionPattern.FindStringSubmatch(output)
+	if match == nil {
+		return "", 0, fmt.Errorf("could not find a version in java output")
+	}
+	version := match[1]
+
+	// Java 8 and older report "1.8.0_392", newer versions "21.0.2" or just "21"
+	parts := strings.FieldsFunc(version, func(r rune) bool { return r == '.' || r == '_' || r == '-' || r == '+' })
+	if len(parts) == 0 {
+		return "", 0, fmt.Errorf("invalid java version %q", version)
+	}
+	majorPart := parts[0]
+	if majorPart == "1" && len(parts) > 1 {
+		majorPart = parts[1]
+	}
+
+	major, err := strconv.Atoi(majorPart)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid java version %q", version)
+	}
+	return version, major, nil
+}
+
+// GetJavaRuntime runs `java -version` on the given binary
+func GetJavaRuntime(javaPath string) (JavaRuntime, error) {
+	// java prints its version to stderr
+	output, err := exec.Command(javaPath, "-version").CombinedOutput()
+	if err != nil {
+		return JavaRuntime{}, fmt.Errorf("failed to run %s -version: %w", javaPath, err)
+	}
+
+	version, major, err := ParseJavaVersion(string(output))
+	if err != nil {
+		return JavaRuntime{}, err
+	}
+
+	return JavaRuntime{Path: javaPath, Version: version, Major: major}, nil
+}
+
+// javaBinary returns the path of the java binary inside a JDK/JRE home
+func javaBinary(home string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(home, "bin", "java.exe")
+	}
+	return filepath.Join(home, "bin", "java")
+}
+
+// javaSearchDir is a directory that contains JDK homes, with the source name to report
+type javaSearchDir struct {
+	dir, source string
+}
+
+// javaSources orders runtimes of the same major version, earlier sources win
+var javaSources = []string{"PATH", "JAVA_HOME", "osmium", "sdkman", "system"}
+
+// javaSearchDirs returns the directories that contain JDK homes, in a fixed order
+func javaSearchDirs() []javaSearchDir {
+	var dirs []javaSearchDir
+
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, javaSearchDir{filepath.Join(home, ".sdkman", "candidates", "java"), "sdkman"})
+	}
+	if sdkman := os.Getenv("SDKMAN_DIR"); sdkman != "" {
+		dirs = append(dirs, javaSearchDir{filepath.Join(sdkman, "candidates", "java"), "sdkman"})
+	}
+
+	switch runtime.GOOS {
+	case "linux", "freebsd":
+		dirs = append(dirs,
+			javaSearchDir{"/usr/lib/jvm", "system"},
+			javaSearchDir{"/usr/java", "system"},
+			javaSearchDir{"/usr/local/openjdk", "system"},
+		)
+	case "darwin":
+		dirs = append(dirs, javaSearchDir{"/Library/Java/JavaVirtualMachines", "system"})
+	case "windows":
+		for _, base := range []string{os.Getenv("ProgramFiles"), os.Getenv("ProgramFiles(x86)")} {
+			if base == "" {
+				continue
+			}
+			for _, vendor := range []string{"Java", "Eclipse Adoptium", "Microsoft", "Zulu", "Amazon Corretto"} {
+				dirs = append(dirs, javaSearchDir{filepath.Join(base, vendor), "system"})
+			}
+		}
+	}
+
+	return dirs
+}
+
+// DiscoverJava finds the java runtimes installed on this machine (including JDKs installed by Osmium), newest first.
+// Runtimes that fail to report a version are skipped.
+func DiscoverJava() []JavaRuntime {
+	type candidate struct{ path, source string }
+	var candidates []candidate
+
+	if path, err := exec.LookPath("java"); err == nil {
+		candidates = append(candidates, candidate{path, "PATH"})
+	}
+	if javaHome := os.Getenv("JAVA_HOME"); javaHome != "" {
+		candidates = append(candidates, candidate{javaBinary(javaHome), "JAVA_HOME"})
+	}
+	if jdks, err := ListInstalledJDKs(); err == nil {
+		for _, jdk := range jdks {
+			candidates = append(candidates, candidate{jdk.JavaPath, "osmium"})
+		}
+	}
+
+	for _, search := range javaSearchDirs() {
+		dir, source := search.dir, search.source
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			home := filepath.Join(dir, entry.Name())
+			// macOS bundles keep the JDK home in Contents/Home
+			if _, err := os.Stat(filepath.Join(home, "Contents", "Home")); err == nil {
+				home = filepath.Join(home, "Contents", "Home")
+			}
+			candidates = append(candidates, candidate{javaBinary(home), source})
+		}
+	}
+
+	var runtimes []JavaRuntime
+	seen := map[string]bool{}
+	for _, c := range candidates {
+		// Distro symlinks (/usr/bin/java -> /usr/lib/jvm/...) point at the same runtime
+		resolved, err := filepath.EvalSymlinks(c.path)
+		if err != nil || seen[resolved] {
+			continue
+		}
+		seen[resolved] = true
+
+		rt, err := GetJavaRuntime(c.path)
+		if err != nil {
+			continue
+		}
+		rt.Path, _ = filepath.Abs(c.path)
+		rt.Source = c.source
+		runtimes = append(runtimes, rt)
+	}
+
+	// Same major: prefer the earlier source, then the path, so the pick doesn't change between runs
+	slices.SortFunc(runtimes, func(a, b JavaRuntime) int {
+		if a.Major != b.Major {
+			return b.Major - a.Major
+		}
+		if a.Source != b.Source {
+			return slices.Index(javaSources, a.Source) - slices.Index(javaSources, b.Source)
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+	return runtimes
+}
+
+// FindJavaForMajor returns the first discovered runtime with the given major version
+func FindJavaForMajor(major int) (JavaRuntime, error) {
+	for _, rt := range DiscoverJava() {
+		if rt.Major == major {
+			return rt, nil
+		}
+	}
+	return JavaRuntime{}, fmt.Errorf("no Java %d installation found", major)
+}
+
+// RequiredJavaMajor returns the minimum Java version a Minecraft version needs:
+// 8 before 1.17, 16 for 1.17, 17 up to 1.20.4, 21 from 1.20.5 and 25 for the year-based 26.x versions.
+func RequiredJavaMajor(mcVersion string) int {
+	parts := strings.Split(mcVersion, ".")
+	nums := make([]int, 3)
+	for i := 0; i < len(parts) && i < 3; i++ {
+		nums[i], _ = strconv.Atoi(parts[i])
+	}
+
+	if nums[0] >= 26 {
+		return 25
+	}
+
+	minor, patch := nums[1], nums[2]
+	switch {
+	case minor < 17:
+		return 8
+	case minor == 17:
+		return 16
+	case minor < 20 || (minor == 20 && patch <= 4):
+		return 17
+	default:
+		return 21
+	}
+}
+
+// CheckJavaCompatibility returns a warning when the runtime can't (or likely can't) run the Minecraft version,
+// or an empty string when it's fine.
+func CheckJavaCompatibility(rt JavaRuntime, mcVersion string) string {
+	required := RequiredJavaMajor(mcVersion)
+
+	if rt.Major < required {
+		return fmt.Sprintf("Minecraft %s needs Java %d or newer, but %s is Java %d", mcVersion, required, rt.Path, rt.Major)
+	}
+	// Pre-1.17 servers and their mod loaders often break on modern Java
+	if required == 8 && rt.Major > 11 {
+		return fmt.Sprintf("Minecraft %s is meant for Java 8 (or 11), %s is Java %d and may fail to start", mcVersion, rt.Path, rt.Major)
+	}
+	return ""
+}
+
+// JavaEnv returns the environment for a process that should use javaPath,
+// so scripts that call plain `java` (Forge/NeoForge run.sh) pick it up as well.
+func JavaEnv(javaPath string) []string {
+	binDir := filepath.Dir(javaPath)
+	env := os.Environ()
+	env = append(env,
+		"JAVA_HOME="+filepath.Dir(binDir),
+		"PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"),
+	)
+	return env
+}
@@ -0,0 +1,390 @@
+package util
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/limelamp/osmium/internal/tui/config"
+)
+
+// AdoptiumAPIURL is the base URL of the Adoptium API, it can be pointed at a mirror or a local stand-in
+var AdoptiumAPIURL = "https://api.adoptium.net"
+
+const (
+	JDKFolder        = "jdks"      // inside the app dir
+	jdkRegistryFile  = "jdks.json" // inside JDKFolder
+	jdkStagingPrefix = ".staging-"
+)
+
+// InstalledJDK is a JDK downloaded by Osmium, recorded in jdks.json
+type InstalledJDK struct {
+	Major       int       `json:"major"`
+	Release     string    `json:"release"`   // e.g. "jdk-21.0.2+13"
+	JavaPath    string    `json:"java_path"` // java binary inside the JDK
+	Checksum    string    `json:"sha256"`    // of the downloaded archive
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// Adoptium API response, only the fields Osmium uses
+type adoptiumAsset struct {
+	Binary struct {
+		Package struct {
+			Checksum string `json:"checksum"`
+			Link     string `json:"link"`
+			Name     string `json:"name"`
+			Size     int64  `json:"size"`
+		} `json:"package"`
+	} `json:"binary"`
+	ReleaseName string `json:"release_name"`
+}
+
+// JDKDir returns the folder Osmium installs JDKs into
+func JDKDir() (string, error) {
+	appDir, err := config.EnsureAppDirExists()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(appDir, JDKFolder), nil
+}
+
+// ListInstalledJDKs reads the JDK registry, an empty list is returned when nothing was installed yet
+func ListInstalledJDKs() ([]InstalledJDK, error) {
+	dir, err := JDKDir()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, jdkRegistryFile))
+	if os.IsNotExist(err) {
+		return []InstalledJDK{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var jdks []InstalledJDK
+	if err := json.Unmarshal(data, &jdks); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", jdkRegistryFile, err)
+	}
+	return jdks, nil
+}
+
+// registerJDK adds or replaces an entry in the JDK registry
+func registerJDK(jdk InstalledJDK) error {
+	jdks, err := ListInstalledJDKs()
+	if err != nil {
+		return err
+	}
+
+	kept := []InstalledJDK{jdk}
+	for _, existing := range jdks {
+		if existing.Release != jdk.Release {
+			kept = append(kept, existing)
+		}
+	}
+
+	data, err := json.MarshalIndent(kept, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir, err := JDKDir()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, jdkRegistryFile), data, 0644)
+}
+
+// adoptiumPlatform maps the host to Adoptium's os and architecture names
+func adoptiumPlatform() (string, string, error) {
+	var osName, arch string
+
+	switch runtime.GOOS {
+	case "linux":
+		osName = "linux"
+	case "darwin":
+		osName = "mac"
+	case "windows":
+		osName = "windows"
+	default:
+		return "", "", fmt.Errorf("Adoptium has no JDK builds for %s", runtime.GOOS)
+	}
+
+	switch runtime.GOARCH {
+	case "amd64":
+		arch = "x64"
+	case "arm64":
+		arch = "aarch64"
+	case "386":
+		arch = "x32"
+	case "arm":
+		arch = "arm"
+	case "ppc64le", "s390x":
+		arch = runtime.GOARCH
+	default:
+		return "", "", fmt.Errorf("Adoptium has no JDK builds for %s", runtime.GOARCH)
+	}
+
+	return osName, arch, nil
+}
+
+// getAdoptiumAsset looks up the latest Temurin JDK of a major version for this host
+func getAdoptiumAsset(major int) (adoptiumAsset, error) {
+	osName, arch, err := adoptiumPlatform()
+	if err != nil {
+		return adoptiumAsset{}, err
+	}
+
+	query := url.Values{}
+	query.Set("architecture", arch)
+	query.Set("os", osName)
+	query.Set("image_type", "jdk")
+	query.Set("vendor", "eclipse")
+	apiURL := fmt.Sprintf("%s/v3/assets/latest/%d/hotspot?%s", strings.TrimSuffix(AdoptiumAPIURL, "/"), major, query.Encode())
+
+	resp, err := getWithStatus(apiURL)
+	if err != nil {
+		return adoptiumAsset{}, fmt.Errorf("failed to query Adoptium for Java %d: %w", major, err)
+	}
+	defer resp.Body.Close()
+
+	var assets []adoptiumAsset
+	if err := json.NewDecoder(resp.Body).Decode(&assets); err != nil {
+		return adoptiumAsset{}, fmt.Errorf("failed to parse Adoptium response: %w", err)
+	}
+	if len(assets) == 0 {
+		return adoptiumAsset{}, fmt.Errorf("no Temurin JDK %d available for %s/%s", major, osName, arch)
+	}
+
+	return assets[0], nil
+}
+
+// safeJoin joins an archive entry name onto dest, refusing entries that escape it
+func safeJoin(dest, name string) (string, error) {
+	target := filepath.Join(dest, name)
+	if target != dest && !strings.HasPrefix(target, dest+string(os.PathSeparator)) {
+		return "", fmt.Errorf("unsafe path in archive: %s", name)
+	}
+	return target, nil
+}
+
+// extractTarGz unpacks a .tar.gz archive into dest, keeping file modes and relative symlinks
+func extractTarGz(archive, dest string) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := safeJoin(dest, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0777)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			out.Close()
+		case tar.TypeSymlink:
+			// Only links that stay inside the JDK
+			if _, err := safeJoin(dest, filepath.Join(filepath.Dir(header.Name), header.Linkname)); err != nil || filepath.IsAbs(header.Linkname) {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// extractZip unpacks a .zip archive into dest
+func extractZip(archive, dest string) error {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for _, f := range reader.File {
+		target, err := safeJoin(dest, f.Name)
+		if err != nil {
+			return err
+		}
+
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		in, err := f.Open()
+		if err != nil {
+			return err
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, f.Mode()|0644)
+		if err != nil {
+			in.Close()
+			return err
+		}
+		_, err = io.Copy(out, in)
+		in.Close()
+		out.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findJDKHome returns the folder containing bin/java below an extracted archive
+func findJDKHome(root string) (string, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return "", err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		for _, home := range []string{filepath.Join(root, entry.Name()), filepath.Join(root, entry.Name(), "Contents", "Home")} {
+			if _, err := os.Stat(javaBinary(home)); err == nil {
+				return home, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no bin/java found in the JDK archive")
+}
+
+// InstallJDK downloads the latest Temurin JDK of the given major version into the app dir,
+// verifies its checksum and registers it. Already installed releases are reused.
+func InstallJDK(major int) (InstalledJDK, error) {
+	asset, err := getAdoptiumAsset(major)
+	if err != nil {
+		return InstalledJDK{}, err
+	}
+	pkg := asset.Binary.Package
+
+	jdks, err := ListInstalledJDKs()
+	if err != nil {
+		return InstalledJDK{}, err
+	}
+	for _, jdk := range jdks {
+		if jdk.Release == asset.ReleaseName {
+			if _, err := os.Stat(jdk.JavaPath); err == nil {
+				return jdk, nil
+			}
+		}
+	}
+
+	dir, err := JDKDir()
+	if err != nil {
+		return InstalledJDK{}, err
+	}
+	staging := filepath.Join(dir, jdkStagingPrefix+asset.ReleaseName)
+	if err := os.RemoveAll(staging); err != nil {
+		return InstalledJDK{}, err
+	}
+	if err := os.MkdirAll(staging, 0755); err != nil {
+		return InstalledJDK{}, err
+	}
+	defer os.RemoveAll(staging)
+
+	// Step 1: Download and verify
+	fmt.Printf("Downloading %s (%d MB)...\n", pkg.Name, pkg.Size/(1024*1024))
+	archive := filepath.Join(staging, filepath.Base(pkg.Name))
+	if err := DownloadVerified(pkg.Link, archive, Checksums{SHA256: pkg.Checksum}); err != nil {
+		return InstalledJDK{}, fmt.Errorf("failed to download JDK: %w", err)
+	}
+
+	// Step 2: Extract
+	extracted := filepath.Join(staging, "extracted")
+	if strings.HasSuffix(pkg.Name, ".zip") {
+		err = extractZip(archive, extracted)
+	} else {
+		err = extractTarGz(archive, extracted)
+	}
+	if err != nil {
+		return InstalledJDK{}, fmt.Errorf("failed to extract JDK: %w", err)
+	}
+
+	home, err := findJDKHome(extracted)
+	if err != nil {
+		return InstalledJDK{}, err
+	}
+
+	// Step 3: Move the JDK into place
+	top, _ := filepath.Rel(extracted, home)
+	top = strings.Split(top, string(os.PathSeparator))[0]
+	finalDir := filepath.Join(dir, asset.ReleaseName)
+	if err := os.RemoveAll(finalDir); err != nil {
+		return InstalledJDK{}, err
+	}
+	if err := os.Rename(filepath.Join(extracted, top), finalDir); err != nil {
+		return InstalledJDK{}, fmt.Errorf("failed to install JDK: %w", err)
+	}
+
+	rel, _ := filepath.Rel(filepath.Join(extracted, top), home)
+	jdk := InstalledJDK{
+		Major:       major,
+		Release:     asset.ReleaseName,
+		JavaPath:    javaBinary(filepath.Join(finalDir, rel)),
+		Checksum:    strings.ToLower(pkg.Checksum),
+		InstalledAt: time.Now(),
+	}
+
+	// Step 4: Register
+	if err := registerJDK(jdk); err != nil {
+		return InstalledJDK{}, err
+	}
+
+	return jdk, nil
+}
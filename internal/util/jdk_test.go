@@ -0,0 +1,171 @@
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// jdkArchive builds a .tar.gz with the given files, names are used as-is
+func jdkArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+// fakeAdoptium serves one JDK release through AdoptiumAPIURL, with the archive and its published checksum,
+// and gives the test its own app dir
+func fakeAdoptium(t *testing.T, archive []byte, checksum string) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v3/assets/latest/21/hotspot"):
+			var asset adoptiumAsset
+			asset.ReleaseName = "jdk-21.0.2+13"
+			asset.Binary.Package.Name = "OpenJDK21U-jdk.tar.gz"
+			asset.Binary.Package.Link = server.URL + "/download/OpenJDK21U-jdk.tar.gz"
+			asset.Binary.Package.Checksum = checksum
+			asset.Binary.Package.Size = int64(len(archive))
+			json.NewEncoder(w).Encode([]adoptiumAsset{asset})
+		case r.URL.Path == "/download/OpenJDK21U-jdk.tar.gz":
+			w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	original := AdoptiumAPIURL
+	AdoptiumAPIURL = server.URL + "/"
+	t.Cleanup(func() { AdoptiumAPIURL = original })
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestInstallJDK(t *testing.T) {
+	archive := jdkArchive(t, map[string]string{
+		"jdk-21.0.2+13/" + filepath.ToSlash(javaBinary("")): "#!/bin/sh\n",
+		"jdk-21.0.2+13/release":                             "JAVA_VERSION=\"21.0.2\"\n",
+	})
+	fakeAdoptium(t, archive, sha256Hex(archive))
+
+	jdk, err := InstallJDK(21)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jdk.Major != 21 || jdk.Release != "jdk-21.0.2+13" {
+		t.Errorf("installed %+v, want jdk-21.0.2+13", jdk)
+	}
+	if _, err := os.Stat(jdk.JavaPath); err != nil {
+		t.Errorf("java binary missing: %v", err)
+	}
+
+	installed, err := ListInstalledJDKs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(installed) != 1 || installed[0].JavaPath != jdk.JavaPath {
+		t.Errorf("registry lists %+v, want the installed JDK", installed)
+	}
+}
+
+func TestInstallJDKRejects(t *testing.T) {
+	good := jdkArchive(t, map[string]string{"jdk-21.0.2+13/" + filepath.ToSlash(javaBinary("")): "#!/bin/sh\n"})
+	escaping := jdkArchive(t, map[string]string{
+		"jdk-21.0.2+13/" + filepath.ToSlash(javaBinary("")): "#!/bin/sh\n",
+		"../../escaped": "gotcha",
+	})
+
+	tests := []struct {
+		name     string
+		archive  []byte
+		checksum string
+		wantErr  string
+	}{
+		{name: "checksum mismatch", archive: good, checksum: strings.Repeat("0", 64), wantErr: "checksum mismatch"},
+		{name: "entry outside the JDK", archive: escaping, checksum: sha256Hex(escaping), wantErr: "unsafe path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeAdoptium(t, tt.archive, tt.checksum)
+
+			_, err := InstallJDK(21)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+
+			installed, err := ListInstalledJDKs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(installed) != 0 {
+				t.Errorf("registry lists %+v after a failed install", installed)
+			}
+
+			dir, _ := JDKDir()
+			entries, _ := os.ReadDir(dir)
+			for _, entry := range entries {
+				t.Errorf("%s left behind in %s", entry.Name(), dir)
+			}
+		})
+	}
+}
+
+func TestSafeJoin(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "extracted")
+
+	tests := []struct {
+		name    string
+		entry   string
+		wantErr bool
+	}{
+		{name: "file", entry: "jdk/bin/java"},
+		{name: "the folder itself", entry: "."},
+		{name: "dot segments inside", entry: "jdk/lib/../bin/java"},
+		{name: "parent", entry: "../evil", wantErr: true},
+		{name: "parent after a folder", entry: "jdk/../../evil", wantErr: true},
+		{name: "sibling with the same prefix", entry: "../extracted-evil/x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := safeJoin(dest, tt.entry)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("safeJoin(%q) = %s, want an error", tt.entry, target)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("safeJoin(%q): %v", tt.entry, err)
+			}
+		})
+	}
+}
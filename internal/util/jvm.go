@@ -0,0 +1,127 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// JVM flag profiles
+const (
+	JVMProfileAikar   = "aikar"   // Aikar's tuned G1 flags, the usual recommendation for Paper/Spigot
+	JVMProfileZGC     = "zgc"     // low-pause ZGC, needs Java 17+ and plenty of memory
+	JVMProfileMinimal = "minimal" // only the heap size
+)
+
+var JVMProfiles = []string{JVMProfileAikar, JVMProfileZGC, JVMProfileMinimal}
+
+const (
+	DefaultJVMProfile = JVMProfileAikar
+	DefaultMemory     = "4G"
+)
+
+// UserJVMArgsFile is read by the run.sh/run.bat scripts that Forge and NeoForge generate
+const UserJVMArgsFile = "user_jvm_args.txt"
+
+// RunOptions controls how the server JVM is launched
+type RunOptions struct {
+	Memory   string   // heap size as stored in servers.json, e.g. "4G" or "4 GB"
+	Profile  string   // one of JVMProfiles, empty means DefaultJVMProfile
+	Flags    []string // extra JVM flags appended after the profile
+	JavaPath string   // java binary to launch with, empty means `java` from PATH
+}
+
+var memoryPattern = regexp.MustCompile(`^(\d+)\s*([GgMm])[Bb]?$`)
+
+// NormalizeMemory turns memory settings like "4 GB", "4g" or "4096MB" into JVM sizes ("4G", "4096M")
+func NormalizeMemory(memory string) (string, error) {
+	memory = strings.TrimSpace(memory)
+	if memory == "" {
+		return DefaultMemory, nil
+	}
+
+	match := memoryPattern.FindStringSubmatch(memory)
+	if match == nil {
+		return "", fmt.Errorf("invalid memory size %q, expected something like 4G or 4096M", memory)
+	}
+	if n, _ := strconv.Atoi(match[1]); n == 0 {
+		return "", fmt.Errorf("memory size %q must be greater than zero", memory)
+	}
+
+	return match[1] + strings.ToUpper(match[2]), nil
+}
+
+// MemoryMegabytes returns a normalized memory size (see NormalizeMemory) in megabytes
+func MemoryMegabytes(memory string) int {
+	n, _ := strconv.Atoi(memory[:len(memory)-1])
+	if strings.HasSuffix(memory, "G") {
+		return n * 1024
+	}
+	return n
+}
+
+// aikarFlags returns Aikar's G1 flags, using his larger young generation settings above 12GB
+func aikarFlags(memory string) []string {
+	newSize, maxNewSize, regionSize, reserve, occupancy := "30", "40", "8M", "20", "15"
+	if MemoryMegabytes(memory) > 12*1024 {
+		newSize, maxNewSize, regionSize, reserve, occupancy = "40", "50", "16M", "15", "20"
+	}
+
+	return []string{
+		"-XX:+UseG1GC",
+		"-XX:+ParallelRefProcEnabled",
+		"-XX:MaxGCPauseMillis=200",
+		"-XX:+UnlockExperimentalVMOptions",
+		"-XX:+DisableExplicitGC",
+		"-XX:+AlwaysPreTouch",
+		"-XX:G1NewSizePercent=" + newSize,
+		"-XX:G1MaxNewSizePercent=" + maxNewSize,
+		"-XX:G1HeapRegionSize=" + regionSize,
+		"-XX:G1ReservePercent=" + reserve,
+		"-XX:G1HeapWastePercent=5",
+		"-XX:G1MixedGCCountTarget=4",
+		"-XX:InitiatingHeapOccupancyPercent=" + occupancy,
+		"-XX:G1MixedGCLiveThresholdPercent=90",
+		"-XX:G1RSetUpdatingPauseTimePercent=5",
+		"-XX:SurvivorRatio=32",
+		"-XX:+PerfDisableSharedMem",
+		"-XX:MaxTenuringThreshold=1",
+		"-Dusing.aikars.flags=https://mcflags.emc.gs",
+		"-Daikars.new.flags=true",
+	}
+}
+
+// JVMArgs returns the JVM arguments (heap size, profile flags and extra flags) for opts
+func JVMArgs(opts RunOptions) ([]string, error) {
+	memory, err := NormalizeMemory(opts.Memory)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"-Xms" + memory, "-Xmx" + memory}
+
+	switch opts.Profile {
+	case "", JVMProfileAikar:
+		args = append(args, aikarFlags(memory)...)
+	case JVMProfileZGC:
+		args = append(args, "-XX:+UseZGC", "-XX:+AlwaysPreTouch", "-XX:+DisableExplicitGC", "-XX:+PerfDisableSharedMem")
+	case JVMProfileMinimal:
+	default:
+		return nil, fmt.Errorf("unknown JVM profile %q, expected one of %s", opts.Profile, strings.Join(JVMProfiles, ", "))
+	}
+
+	return append(args, opts.Flags...), nil
+}
+
+// WriteUserJVMArgs writes the JVM arguments to user_jvm_args.txt for Forge/NeoForge's run scripts
+func WriteUserJVMArgs(args []string) error {
+	content := "# Managed by Osmium, change the server's JVM profile with 'osmium jvm' instead of editing this file.\n"
+	content += strings.Join(args, "\n") + "\n"
+
+	if err := os.WriteFile(UserJVMArgsFile, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", UserJVMArgsFile, err)
+	}
+	return nil
+}
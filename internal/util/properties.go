@@ -0,0 +1,55 @@
+package util
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// ReadServerProperties parses a server.properties file into a key/value map.
+// Comments and malformed lines are skipped.
+func ReadServerProperties(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	properties := make(map[string]string)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Split by the first "="
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) == 2 {
+			properties[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return properties, nil
+}
+
+// GetLevelName returns the world folder name configured in server.properties,
+// falling back to Minecraft's default of "world".
+func GetLevelName() string {
+	properties, err := ReadServerProperties("server.properties")
+	if err != nil {
+		return "world"
+	}
+
+	if name := properties["level-name"]; name != "" {
+		return name
+	}
+
+	return "world"
+}
@@ -0,0 +1,128 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Run scripts generated next to the server files
+const (
+	RunScriptSh  = "run_server.sh"
+	RunScriptBat = "run_server.bat"
+)
+
+// RunScriptOptions controls the generated run scripts
+type RunScriptOptions struct {
+	Loader         string     // loader from osmium.json, decides the entrypoint
+	Run            RunOptions // memory, JVM profile, extra flags and java path
+	RestartOnCrash bool       // start the server again when it exits with an error
+}
+
+// quoteArg quotes an argument that contains spaces, the same way for sh and cmd
+func quoteArg(arg string) string {
+	if strings.ContainsAny(arg, " \t") {
+		return `"` + arg + `"`
+	}
+	return arg
+}
+
+// scriptCommands returns the line that starts the server for sh and for bat.
+// Forge and NeoForge go through their own run scripts, which read user_jvm_args.txt.
+func scriptCommands(opts RunScriptOptions, jvmArgs []string) (string, string) {
+	if opts.Loader == "Forge" || opts.Loader == "NeoForge" {
+		return "sh ./run.sh nogui", "call run.bat nogui"
+	}
+
+	javaPath, args := GetServerRunCommand(opts.Loader, opts.Run.JavaPath, jvmArgs)
+
+	parts := []string{quoteArg(javaPath)}
+	for _, arg := range args {
+		parts = append(parts, quoteArg(arg))
+	}
+	line := strings.Join(parts, " ")
+
+	return line, line
+}
+
+// BuildRunScripts returns the contents of run_server.sh and run_server.bat for opts
+func BuildRunScripts(opts RunScriptOptions) (string, string, error) {
+	jvmArgs, err := JVMArgs(opts.Run)
+	if err != nil {
+		return "", "", err
+	}
+	shCommand, batCommand := scriptCommands(opts, jvmArgs)
+
+	var sh, bat []string
+
+	sh = append(sh, "#!/bin/sh", "", `cd "$(dirname "$0")"`)
+	bat = append(bat, "@echo off", "", `cd /d "%~dp0"`)
+
+	// Forge/NeoForge scripts call plain java, so a pinned one has to come first on PATH
+	if opts.Run.JavaPath != "" {
+		binDir := filepath.Dir(opts.Run.JavaPath)
+		sh = append(sh, fmt.Sprintf(`export JAVA_HOME="%s"`, filepath.Dir(binDir)), fmt.Sprintf(`export PATH="%s:$PATH"`, binDir))
+		bat = append(bat, fmt.Sprintf(`set "JAVA_HOME=%s"`, filepath.Dir(binDir)), fmt.Sprintf(`set "PATH=%s;%%PATH%%"`, binDir))
+	}
+	sh = append(sh, "")
+	bat = append(bat, "")
+
+	if opts.RestartOnCrash {
+		sh = append(sh,
+			"while true; do",
+			"  "+shCommand,
+			`  if [ $? -eq 0 ]; then`,
+			"    break",
+			"  fi",
+			`  echo "Server crashed, restarting in 10 seconds (press Ctrl+C to cancel)..."`,
+			"  sleep 10",
+			"done",
+		)
+		bat = append(bat,
+			":start",
+			batCommand,
+			"if %errorlevel% equ 0 goto end",
+			"echo Server crashed, restarting in 10 seconds (press Ctrl+C to cancel)...",
+			"timeout /t 10 /nobreak > nul",
+			"goto start",
+			":end",
+		)
+	} else {
+		sh = append(sh, shCommand)
+		bat = append(bat, batCommand)
+	}
+
+	return strings.Join(sh, "\n") + "\n", strings.Join(bat, "\r\n") + "\r\n", nil
+}
+
+// WriteRunScripts writes run_server.sh and run_server.bat into the current directory,
+// plus user_jvm_args.txt for Forge and NeoForge. The written file names are returned.
+func WriteRunScripts(opts RunScriptOptions) ([]string, error) {
+	sh, bat, err := BuildRunScripts(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	written := []string{RunScriptSh, RunScriptBat}
+
+	if opts.Loader == "Forge" || opts.Loader == "NeoForge" {
+		jvmArgs, err := JVMArgs(opts.Run)
+		if err != nil {
+			return nil, err
+		}
+		if err := WriteUserJVMArgs(jvmArgs); err != nil {
+			return nil, err
+		}
+		written = append(written, UserJVMArgsFile)
+	}
+
+	if err := os.WriteFile(RunScriptSh, []byte(sh), 0755); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", RunScriptSh, err)
+	}
+	if err := os.WriteFile(RunScriptBat, []byte(bat), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", RunScriptBat, err)
+	}
+
+	return written, nil
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"os/exec"
@@ -30,7 +29,8 @@ type vanillaVersionManifest struct {
 type vanillaVersionPackage struct {
 	Downloads struct {
 		Server struct {
-			URL string `json:"url"`
+			URL  string `json:"url"`
+			SHA1 string `json:"sha1"`
 		} `json:"server"`
 	} `json:"downloads"`
 }
@@ -43,7 +43,8 @@ type paperBuilds struct {
 type paperDownloads struct {
 	Downloads struct {
 		Application struct {
-			Name string `json:"name"`
+			Name   string `json:"name"`
+			SHA256 string `json:"sha256"`
 		} `json:"application"`
 	} `json:"downloads"`
 }
@@ -138,17 +139,18 @@ func GetVersionStrings(version_type string) ([]string, error) {
 	return versionList, nil
 }
 
-func getVanillaServerURL(targetVersion string) (string, error) {
+// getVanillaServerURL returns the server jar URL of a version and the sha1 Mojang publishes for it
+func getVanillaServerURL(targetVersion string) (string, string, error) {
 	// Step 1: Get the Master Manifest
 	resp, err := getWithStatus("https://launchermeta.mojang.com/mc/game/version_manifest.json")
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	defer resp.Body.Close()
 
 	var manifest vanillaVersionManifest
 	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	// Step 2: Find the specific version in the list
@@ -161,40 +163,41 @@ func getVanillaServerURL(targetVersion string) (string, error) {
 	}
 
 	if versionDetailURL == "" {
-		return "", fmt.Errorf("version %s not found", targetVersion)
+		return "", "", fmt.Errorf("version %s not found", targetVersion)
 	}
 
 	// Step 3: Get the specific version's JSON package
 	resp, err = getWithStatus(versionDetailURL)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	defer resp.Body.Close()
 
 	var pkg vanillaVersionPackage
 	if err := json.NewDecoder(resp.Body).Decode(&pkg); err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	return pkg.Downloads.Server.URL, nil
+	return pkg.Downloads.Server.URL, pkg.Downloads.Server.SHA1, nil
 }
 
-func getPaperServerURL(version string) (string, error) {
+// getPaperServerURL returns the jar URL of the latest build of a version and its sha256
+func getPaperServerURL(version string) (string, string, error) {
 	// Step 1: Get the builds list to find the LATEST build number
 	buildsURL := fmt.Sprintf("https://api.papermc.io/v2/projects/paper/versions/%s", version)
 	resp, err := getWithStatus(buildsURL)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	defer resp.Body.Close()
 
 	var buildsData paperBuilds
 	if err := json.NewDecoder(resp.Body).Decode(&buildsData); err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	if len(buildsData.Builds) == 0 {
-		return "", fmt.Errorf("no builds found for version %s", version)
+		return "", "", fmt.Errorf("no builds found for version %s", version)
 	}
 	latestBuild := buildsData.Builds[len(buildsData.Builds)-1]
 
@@ -202,13 +205,13 @@ func getPaperServerURL(version string) (string, error) {
 	infoURL := fmt.Sprintf("https://api.papermc.io/v2/projects/paper/versions/%s/builds/%d", version, latestBuild)
 	resp, err = getWithStatus(infoURL)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	defer resp.Body.Close()
 
 	var info paperDownloads
 	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
-		return "", err
+		return "", "", err
 	}
 	filename := info.Downloads.Application.Name
 
@@ -221,7 +224,7 @@ func getPaperServerURL(version string) (string, error) {
 	// fmt.Println(finalURL)
 	// return finalURL, nil
 
-	return finalURL, nil
+	return finalURL, info.Downloads.Application.SHA256, nil
 }
 
 func getFabricServerURL(mcVersion string) (string, error) {
@@ -546,56 +549,70 @@ func getQuiltInstallerURL() (string, string, error) {
 // 	return nil
 // }
 
-func downloadFile(url string, filename string) error {
-	resp, err := getWithStatus(url)
-	if err != nil {
+// serverJarAlias is the download cache alias of a loader's server.jar
+func serverJarAlias(jarType, jarVersion string) string {
+	return fmt.Sprintf("server:%s:%s", jarType, jarVersion)
+}
+
+// cachedServerJar falls back to the cached server.jar of a loader and version when it can't be downloaded.
+// Installer based loaders need the network to run the installer, so they return err unchanged.
+func cachedServerJar(jarType, jarVersion string, err error) error {
+	switch jarType {
+	case "NeoForge", "Forge", "Quilt":
 		return err
 	}
-	defer resp.Body.Close()
 
-	out, err := os.Create(filename)
-	if err != nil {
+	ok, linkErr := LinkCacheAlias(serverJarAlias(jarType, jarVersion), "server.jar")
+	if linkErr != nil {
+		return linkErr
+	}
+	if !ok {
 		return err
 	}
-	defer out.Close()
 
-	_, err = io.Copy(out, resp.Body)
-	if err != nil {
-		return err
+	if !Offline {
+		fmt.Printf("⚠ Download failed (%v), using the cached server.jar\n", err)
 	}
+	fmt.Println("Download finished: ", "server.jar")
 	return nil
 }
 
 func DownloadJar(jarType string, jarVersion string) error {
+	// Offline, the server jar can only come from an earlier download of the same loader and version
+	if Offline {
+		return cachedServerJar(jarType, jarVersion, fmt.Errorf("server.jar for %s %s is %w (offline mode)", jarType, jarVersion, ErrNotCached))
+	}
+
 	// Deciding which url
 	url := ""
+	var sums Checksums // published hashes, only some sources have them
 	var err error
 	installerFilename := ""
 	isInstaller := false
 
 	switch jarType {
 	case "Vanilla":
-		url, err = getVanillaServerURL(jarVersion)
+		url, sums.SHA1, err = getVanillaServerURL(jarVersion)
 		if err != nil {
-			return err
+			return cachedServerJar(jarType, jarVersion, err)
 		}
 	case "Paper":
-		url, err = getPaperServerURL(jarVersion)
+		url, sums.SHA256, err = getPaperServerURL(jarVersion)
 		if err != nil {
-			return err
+			return cachedServerJar(jarType, jarVersion, err)
 		}
 	case "Purpur":
 		url = "https://api.purpurmc.org/v2/purpur/" + jarVersion + "/latest/download"
 	case "Fabric":
 		url, err = getFabricServerURL(jarVersion)
 		if err != nil {
-			return err
+			return cachedServerJar(jarType, jarVersion, err)
 		}
 	case "NeoForge":
 		var neoforgeVer string
 		url, neoforgeVer, err = getNeoForgeServerURL(jarVersion)
 		if err != nil {
-			return err
+			return cachedServerJar(jarType, jarVersion, err)
 		}
 		installerFilename = fmt.Sprintf("neoforge-%s-installer.jar", neoforgeVer)
 		isInstaller = true
@@ -603,7 +620,7 @@ func DownloadJar(jarType string, jarVersion string) error {
 		var forgeVer string
 		url, forgeVer, err = getForgeServerURL(jarVersion)
 		if err != nil {
-			return err
+			return cachedServerJar(jarType, jarVersion, err)
 		}
 		installerFilename = fmt.Sprintf("forge-%s-%s-installer.jar", jarVersion, forgeVer)
 		isInstaller = true
@@ -611,14 +628,14 @@ func DownloadJar(jarType string, jarVersion string) error {
 		var quiltInstallerVer string
 		url, quiltInstallerVer, err = getQuiltInstallerURL()
 		if err != nil {
-			return err
+			return cachedServerJar(jarType, jarVersion, err)
 		}
 		installerFilename = fmt.Sprintf("quilt-installer-%s.jar", quiltInstallerVer)
 		isInstaller = true
 	case "Youer":
 		url, err = getYouerServerURL(jarVersion)
 		if err != nil {
-			return err
+			return cachedServerJar(jarType, jarVersion, err)
 		}
 	}
 
@@ -630,12 +647,18 @@ func DownloadJar(jarType string, jarVersion string) error {
 
 	fmt.Println("Downloading the required files....")
 
-	if err := downloadFile(url, output); err != nil {
-		return err
+	if err := DownloadCached(url, output, sums); err != nil {
+		return cachedServerJar(jarType, jarVersion, err)
 	}
 
 	fmt.Println("Download finished: ", output)
 
+	if !isInstaller {
+		if err := SetCacheAlias(serverJarAlias(jarType, jarVersion), output); err != nil {
+			fmt.Printf("⚠ Couldn't add %s to the download cache: %v\n", output, err)
+		}
+	}
+
 	// If it's an installer (NeoForge, Forge, Quilt), run the installer
 	if isInstaller {
 		fmt.Println("Running installer...")
@@ -698,8 +721,14 @@ func RunModLoaderInstaller(loaderType string, installerFile string, mcVersion ..
 	return nil
 }
 
-// GetServerRunCommand returns the command needed to run the server for a given jar type
-func GetServerRunCommand(jarType string) (string, []string) {
+// GetServerRunCommand returns the command needed to run the server for a given jar type.
+// jvmArgs (see JVMArgs) go before -jar; Forge and NeoForge read them from user_jvm_args.txt instead.
+// An empty javaPath means `java` from PATH.
+func GetServerRunCommand(jarType string, javaPath string, jvmArgs []string) (string, []string) {
+	if javaPath == "" {
+		javaPath = "java"
+	}
+
 	switch jarType {
 	case "NeoForge", "Forge":
 		// NeoForge and Forge create run.bat/run.sh after installation - use those directly
@@ -718,9 +747,9 @@ func GetServerRunCommand(jarType string) (string, []string) {
 		}
 	case "Quilt":
 		// Quilt creates quilt-server-launch.jar after installation
-		return "java", []string{"-jar", "./server/server.jar", "nogui"}
+		return javaPath, append(jvmArgs, "-jar", "./server/server.jar", "nogui")
 	default:
 		// Standard server.jar execution
-		return "java", []string{"-jar", "-Xms4G", "server.jar", "nogui"}
+		return javaPath, append(jvmArgs, "-jar", "server.jar", "nogui")
 	}
 }